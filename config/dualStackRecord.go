@@ -0,0 +1,112 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+)
+
+// DualStackRecord manages a pair of A and AAAA records with the same name, which are updated together from the
+// addresses of a host.
+type DualStackRecord struct {
+	Name string
+	A    *DnsRecord
+	AAAA *DnsRecord
+}
+
+// AddDualStackRecord adds A and AAAA records with the passed name to the domain, and returns the group that manages
+// them.  Both records are created when an address of their family is first set, and deleted when the host no
+// longer has any address of their family.
+func (d *Domain) AddDualStackRecord(name string) *DualStackRecord {
+	aRecord := d.AddDnsRecord("A", name)
+	aRecord.EnsureExists = true
+	aaaaRecord := d.AddDnsRecord("AAAA", name)
+	aaaaRecord.EnsureExists = true
+
+	dualStackRecord := &DualStackRecord{
+		Name: name,
+		A:    aRecord,
+		AAAA: aaaaRecord,
+	}
+	d.DualStackRecords[name] = dualStackRecord
+
+	return dualStackRecord
+}
+
+// UpdateIpAddresses updates the records from a list of addresses, such as the new value of an interface address
+// change event.  See UpdateAddresses.
+func (r *DualStackRecord) UpdateIpAddresses(addresses []net.IP) error {
+	addrs := make([]netip.Addr, 0, len(addresses))
+
+	for _, address := range addresses {
+		if addr, ok := netip.AddrFromSlice(address); ok {
+			addrs = append(addrs, addr)
+		}
+	}
+
+	return r.UpdateAddresses(addrs)
+}
+
+// UpdateAddresses sets the A record to the first public IPv4 address, and the AAAA record to the first public IPv6
+// address in the list.  Loopback, link-local, multicast and unspecified addresses are ignored.  A record is deleted
+// if the list has no other address of its family.  Private (RFC 1918 and unique local) addresses are not published,
+// but a family with only private addresses, such as IPv4 behind NAT, leaves its record unchanged.
+func (r *DualStackRecord) UpdateAddresses(addresses []netip.Addr) error {
+	var ipV4, ipV6 familyAddress
+
+	for _, address := range addresses {
+		address = address.Unmap()
+
+		if address.Is4() {
+			ipV4.add(address)
+		} else if address.Is6() {
+			ipV6.add(address)
+		}
+	}
+
+	return errors.Join(
+		updateOrDeleteFamilyRecord(r.A, ipV4),
+		updateOrDeleteFamilyRecord(r.AAAA, ipV6))
+}
+
+// familyAddress collects the addresses of one family.
+type familyAddress struct {
+	// present is true if the host has a global unicast address of the family, public or private
+	present bool
+	// public is the first public address of the family
+	public netip.Addr
+}
+
+func (f *familyAddress) add(address netip.Addr) {
+	if !address.IsGlobalUnicast() {
+		return
+	}
+
+	f.present = true
+
+	if !f.public.IsValid() && !address.IsPrivate() {
+		f.public = address
+	}
+}
+
+func updateOrDeleteFamilyRecord(record *DnsRecord, address familyAddress) error {
+	if record.entityStub == nil {
+		return fmt.Errorf("unable to update DNS record %s %s: entity stub is not available", record.Type, record.Name)
+	}
+
+	if address.public.IsValid() {
+		return record.UpdateValue(address.public.String())
+	}
+
+	if address.present {
+		return nil
+	}
+
+	// Avoid calling the API for every event when the record is already gone
+	if record.entityStub.Data().Absent {
+		return nil
+	}
+
+	return record.Delete()
+}
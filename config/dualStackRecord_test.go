@@ -0,0 +1,140 @@
+package config
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/avanha/pmaas-plugin-porkbun/data"
+	"github.com/avanha/pmaas-plugin-porkbun/entities"
+)
+
+// fakeDnsRecord records the calls made to the entity stub of a config record.
+type fakeDnsRecord struct {
+	entities.DnsRecord
+	absent  bool
+	value   string
+	deleted bool
+}
+
+func (r *fakeDnsRecord) UpdateValue(value string) error {
+	r.value = value
+	r.absent = false
+
+	return nil
+}
+
+func (r *fakeDnsRecord) Delete() error {
+	r.deleted = true
+	r.absent = true
+
+	return nil
+}
+
+func (r *fakeDnsRecord) Data() data.DnsRecordData {
+	return data.DnsRecordData{Value: r.value, Absent: r.absent}
+}
+
+func TestDualStackRecordUpdateAddresses(t *testing.T) {
+	tests := []struct {
+		name        string
+		addresses   []string
+		aValue      string
+		aDeleted    bool
+		aaaaValue   string
+		aaaaDeleted bool
+	}{
+		{
+			name:      "public addresses",
+			addresses: []string{"192.0.2.1", "fe80::1", "2001:db8::1", "2001:db8::2"},
+			aValue:    "192.0.2.1",
+			aaaaValue: "2001:db8::1",
+		},
+		{
+			name:      "IPv4-mapped IPv6",
+			addresses: []string{"::ffff:192.0.2.1", "2001:db8::1"},
+			aValue:    "192.0.2.1",
+			aaaaValue: "2001:db8::1",
+		},
+		{
+			name:      "public address after a private one",
+			addresses: []string{"10.0.0.2", "192.0.2.1", "fd00::1", "2001:db8::1"},
+			aValue:    "192.0.2.1",
+			aaaaValue: "2001:db8::1",
+		},
+		{
+			name:      "private IPv4 only",
+			addresses: []string{"10.0.0.2", "2001:db8::1"},
+			aaaaValue: "2001:db8::1",
+		},
+		{
+			name:        "no IPv6",
+			addresses:   []string{"192.0.2.1", "fe80::1", "::1"},
+			aValue:      "192.0.2.1",
+			aaaaDeleted: true,
+		},
+		{
+			name:        "no addresses",
+			addresses:   []string{},
+			aDeleted:    true,
+			aaaaDeleted: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			group := NewDomain("example.com").AddDualStackRecord("www")
+			aStub := &fakeDnsRecord{}
+			aaaaStub := &fakeDnsRecord{}
+			group.A.entityStub = aStub
+			group.AAAA.entityStub = aaaaStub
+			addresses := make([]netip.Addr, len(test.addresses))
+
+			for i, address := range test.addresses {
+				addresses[i] = netip.MustParseAddr(address)
+			}
+
+			if err := group.UpdateAddresses(addresses); err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if aStub.value != test.aValue || aStub.deleted != test.aDeleted {
+				t.Errorf("got A value %q and deleted %t, want %q and %t", aStub.value, aStub.deleted,
+					test.aValue, test.aDeleted)
+			}
+
+			if aaaaStub.value != test.aaaaValue || aaaaStub.deleted != test.aaaaDeleted {
+				t.Errorf("got AAAA value %q and deleted %t, want %q and %t", aaaaStub.value, aaaaStub.deleted,
+					test.aaaaValue, test.aaaaDeleted)
+			}
+		})
+	}
+}
+
+func TestDualStackRecordSkipsDeleteOfAbsentRecord(t *testing.T) {
+	group := NewDomain("example.com").AddDualStackRecord("www")
+	aStub := &fakeDnsRecord{absent: true}
+	group.A.entityStub = aStub
+	group.AAAA.entityStub = &fakeDnsRecord{}
+
+	if err := group.UpdateAddresses([]netip.Addr{netip.MustParseAddr("2001:db8::1")}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if aStub.deleted {
+		t.Error("got a delete, want none for an absent record")
+	}
+}
+
+func TestAddDualStackRecord(t *testing.T) {
+	domain := NewDomain("example.com")
+	group := domain.AddDualStackRecord("www")
+
+	if domain.DualStackRecords["www"] != group || len(domain.DnsRecords) != 2 {
+		t.Errorf("got groups %v and records %v, want the group and its two records", domain.DualStackRecords,
+			domain.DnsRecords)
+	}
+
+	if !group.A.EnsureExists || !group.AAAA.EnsureExists || group.A.Type != "A" || group.AAAA.Type != "AAAA" {
+		t.Errorf("got A %+v and AAAA %+v, want records that are created on the first update", group.A, group.AAAA)
+	}
+}
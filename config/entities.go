@@ -1,22 +1,34 @@
 package config
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"slices"
 
+	"github.com/avanha/pmaas-plugin-porkbun/data"
 	"github.com/avanha/pmaas-plugin-porkbun/entities"
 	"github.com/avanha/pmaas-plugin-porkbun/events"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/validation"
 )
 
 type Domain struct {
 	Name       string
 	DnsRecords map[string]*DnsRecord
+	// DualStackRecords are the groups added by AddDualStackRecord, by name.  Their records are also in DnsRecords.
+	DualStackRecords map[string]*DualStackRecord
+	// DiscoverRecords causes the plugin to retrieve the whole zone on every refresh, and register a read-only
+	// entity for every record that isn't explicitly configured.
+	DiscoverRecords bool
+	// Poll, if set, overrides PluginConfig.Poll for the domain's records and zone retrieval.
+	Poll *PollConfig
 }
 
 func NewDomain(name string) *Domain {
 	return &Domain{
-		Name:       name,
-		DnsRecords: make(map[string]*DnsRecord),
+		Name:             name,
+		DnsRecords:       make(map[string]*DnsRecord),
+		DualStackRecords: make(map[string]*DualStackRecord),
 	}
 }
 
@@ -34,11 +46,62 @@ func (d *Domain) AddDnsRecord(recordType string, name string) *DnsRecord {
 }
 
 type DnsRecord struct {
-	Type                           string
-	Name                           string
-	Value                          string
+	Type     string
+	Name     string
+	Value    string
+	Ttl      int32
+	Priority int32
+	Notes    string
+	// EnsureExists causes the plugin to create the record, using Value, Ttl, Priority and Notes,
+	// if it does not exist in the zone.  Without a Value, the record is created by the first value update.
+	EnsureExists bool
+	// Absent causes the plugin to delete the record, if present, on start and on every refresh.
+	Absent bool
+	// Reconcile makes Value, Ttl, Priority and Notes the desired state of the record.  The plugin compares the
+	// record against them on every refresh, and updates or creates the record if it differs.  Requires a Value.
+	Reconcile bool
+	// WatchDrift causes the plugin to report changes made outside the plugin, for example, in the Porkbun web UI.  A
+	// refresh that finds the record differs from the state last written by the plugin records a drift, and broadcasts
+	// a DnsRecordDriftDetectedEvent.  The record is not corrected, see Reconcile.
+	WatchDrift bool
+	// TrackPublicIp causes the plugin to update A and AAAA records with the public IPv4 or IPv6 address of the
+	// host, whenever it changes.
+	TrackPublicIp bool
+	// Poll, if set, overrides the poll config of the domain for the record.
+	Poll *PollConfig
+
 	entityStub                     entities.DnsRecord
 	onEntityStubAvailableListeners []func(event events.DnsRecordEntityStubAvailableEvent)
+	eventListeners                 []func(event any)
+}
+
+// Validate checks the record type, name, TTL and priority, the value, if set, against the rules of the record type,
+// and that the options can be applied to the record.
+func (r *DnsRecord) Validate() error {
+	errs := []error{
+		validation.ValidateType(r.Type),
+		validation.ValidateName(r.Name),
+		validation.ValidateTtl(r.Ttl),
+		validation.ValidatePriority(r.Priority),
+	}
+
+	if r.Value != "" {
+		errs = append(errs, validation.ValidateContent(r.Type, r.Value))
+	}
+
+	if r.Reconcile && !r.Absent && r.Value == "" {
+		errs = append(errs, errors.New("Reconcile requires a Value"))
+	}
+
+	if r.TrackPublicIp && r.Type != "A" && r.Type != "AAAA" {
+		errs = append(errs, errors.New("TrackPublicIp requires an A or AAAA record"))
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("invalid DNS record %s %s: %w", r.Type, r.Name, err)
+	}
+
+	return nil
 }
 
 func (r *DnsRecord) AddOnEntityStubAvailableListener(eventListener func(event events.DnsRecordEntityStubAvailableEvent)) {
@@ -53,6 +116,79 @@ func (r *DnsRecord) UpdateValue(value string) error {
 	return r.entityStub.UpdateValue(value)
 }
 
+// UpdateValueAndWait updates the value of the record and blocks until the update is resolved, after all retries, or
+// ctx is done.
+func (r *DnsRecord) UpdateValueAndWait(ctx context.Context, value string) (data.DnsRecordUpdateResult, error) {
+	if r.entityStub == nil {
+		err := fmt.Errorf("unable to update DNS record %s %s: entity stub is not available", r.Type, r.Name)
+		return data.DnsRecordUpdateResult{Outcome: data.DnsRecordUpdateFailed, Error: err}, err
+	}
+
+	return r.entityStub.UpdateValueAndWait(ctx, value)
+}
+
+func (r *DnsRecord) Update(spec data.DnsRecordSpec) error {
+	if r.entityStub == nil {
+		return fmt.Errorf("unable to update DNS record %s %s: entity stub is not available", r.Type, r.Name)
+	}
+
+	return r.entityStub.Update(spec)
+}
+
+func (r *DnsRecord) Delete() error {
+	if r.entityStub == nil {
+		return fmt.Errorf("unable to delete DNS record %s %s: entity stub is not available", r.Type, r.Name)
+	}
+
+	return r.entityStub.Delete()
+}
+
+// AddOnValueChangedListener registers a listener for value changes, invoked on the Goroutine that called PMAAS.Run().
+func (r *DnsRecord) AddOnValueChangedListener(eventListener func(event events.DnsRecordValueChangedEvent)) {
+	addEventListener(r, eventListener)
+}
+
+// AddOnUpdateFailedListener registers a listener for failed updates, invoked on the Goroutine that called
+// PMAAS.Run().
+func (r *DnsRecord) AddOnUpdateFailedListener(eventListener func(event events.DnsRecordUpdateFailedEvent)) {
+	addEventListener(r, eventListener)
+}
+
+// AddOnRetrievalFailedListener registers a listener for failed refreshes, invoked on the Goroutine that called
+// PMAAS.Run().
+func (r *DnsRecord) AddOnRetrievalFailedListener(eventListener func(event events.DnsRecordRetrievalFailedEvent)) {
+	addEventListener(r, eventListener)
+}
+
+// AddOnCreatedListener registers a listener for record creation, invoked on the Goroutine that called PMAAS.Run().
+func (r *DnsRecord) AddOnCreatedListener(eventListener func(event events.DnsRecordCreatedEvent)) {
+	addEventListener(r, eventListener)
+}
+
+// AddOnDeletedListener registers a listener for record deletion, invoked on the Goroutine that called PMAAS.Run().
+func (r *DnsRecord) AddOnDeletedListener(eventListener func(event events.DnsRecordDeletedEvent)) {
+	addEventListener(r, eventListener)
+}
+
+// AddOnDriftDetectedListener registers a listener for changes made outside the plugin, invoked on the Goroutine that
+// called PMAAS.Run().  Requires WatchDrift.
+func (r *DnsRecord) AddOnDriftDetectedListener(eventListener func(event events.DnsRecordDriftDetectedEvent)) {
+	addEventListener(r, eventListener)
+}
+
+// addEventListener adapts a typed listener to receive the runtime events of the record, ignoring other event types.
+func addEventListener[E any](r *DnsRecord, eventListener func(event E)) {
+	r.eventListeners = append(r.eventListeners, func(event any) {
+		if typedEvent, ok := event.(E); ok {
+			eventListener(typedEvent)
+		}
+	})
+}
+
+func (r *DnsRecord) EventListeners() []func(event any) {
+	return slices.Clone(r.eventListeners)
+}
+
 func (r *DnsRecord) OnEntityStubAvailableListeners() []func(event events.DnsRecordEntityStubAvailableEvent) {
 	return slices.Clone(r.onEntityStubAvailableListeners)
 }
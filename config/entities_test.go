@@ -0,0 +1,54 @@
+package config
+
+import "testing"
+
+func TestDnsRecordValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		record DnsRecord
+		valid  bool
+	}{
+		{name: "valid", record: DnsRecord{Type: "A", Name: "www", Value: "192.0.2.1", Ttl: 600}, valid: true},
+		{name: "without value", record: DnsRecord{Type: "A", Name: "www"}, valid: true},
+		{name: "unsupported type", record: DnsRecord{Type: "PTR", Name: "www"}, valid: false},
+		{name: "invalid name", record: DnsRecord{Type: "A", Name: "-www"}, valid: false},
+		{name: "invalid value", record: DnsRecord{Type: "A", Name: "www", Value: "2001:db8::1"}, valid: false},
+		{name: "low TTL", record: DnsRecord{Type: "A", Name: "www", Ttl: 60}, valid: false},
+		{name: "reconcile without value", record: DnsRecord{Type: "A", Name: "www", Reconcile: true}, valid: false},
+		{
+			name:   "reconcile absent record",
+			record: DnsRecord{Type: "A", Name: "www", Reconcile: true, Absent: true},
+			valid:  true,
+		},
+		{name: "track public IP", record: DnsRecord{Type: "AAAA", Name: "www", TrackPublicIp: true}, valid: true},
+		{
+			name:   "track public IP of a TXT record",
+			record: DnsRecord{Type: "TXT", Name: "www", TrackPublicIp: true},
+			valid:  false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := test.record.Validate(); (err == nil) != test.valid {
+				t.Errorf("got error %v, want valid %t", err, test.valid)
+			}
+		})
+	}
+}
+
+func TestPluginConfigValidate(t *testing.T) {
+	pluginConfig := PluginConfig{Domains: make(map[string]*Domain)}
+	domain := pluginConfig.AddDomain("example.com")
+	domain.AddDnsRecord("A", "www").Value = "192.0.2.1"
+
+	if err := pluginConfig.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	domain.AddDnsRecord("A", "mail").Value = "mail.example.com"
+
+	if err := pluginConfig.Validate(); err == nil {
+		t.Error("got no error, want an error for the invalid record")
+	}
+}
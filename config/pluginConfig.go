@@ -1,9 +1,192 @@
 package config
 
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/credentials"
+	"github.com/avanha/pmaas-plugin-porkbun/state"
+	spicommon "github.com/avanha/pmaas-spi/common"
+)
+
+// DefaultStateFile is the file, relative to the working directory, where the state of the records is saved
+const DefaultStateFile = "porkbun_state.json"
+
+// DefaultApiBaseUrl is the base URL of the Porkbun JSON API
+const DefaultApiBaseUrl = "https://api.porkbun.com/api/json/v3"
+
 type PluginConfig struct {
+	// ApiKey and ApiSecret are the API credentials, unless Credentials is set.
 	ApiKey    string
 	ApiSecret string
-	Domains   map[string]*Domain
+	// Credentials, if set, supplies the API credentials before each API call, instead of ApiKey and ApiSecret, so
+	// they can be rotated without restarting.  See the credentials package for providers reading environment
+	// variables and files, and for callbacks.
+	Credentials credentials.Provider
+	// ApiBaseUrl is the base URL of the API endpoints, which can be changed to point at a stand-in for the Porkbun
+	// API.  Defaults to DefaultApiBaseUrl.
+	ApiBaseUrl string
+	// HttpClient is used for all API calls.  Defaults to spicommon.DefaultHttpClient when nil.
+	HttpClient spicommon.HttpClient
+	Retry      RetryConfig
+	// Poll is the schedule on which records are refreshed, which can be overridden by Domain.Poll and DnsRecord.Poll.
+	Poll      PollConfig
+	RateLimit RateLimitConfig
+	// Workers is the number of API calls made concurrently.  Defaults to 1.
+	Workers  int
+	PublicIp PublicIpConfig
+	// StateStore saves the state of the configured records, so it survives restarts.  When nil, the state is saved
+	// to StateFile, unless that is empty too.
+	StateStore state.Store
+	StateFile  string
+	Domains    map[string]*Domain
+	// Logger receives the log entries of the plugin.  Defaults to slog.Default() when nil.  The API credentials are
+	// never logged.
+	Logger *slog.Logger
+}
+
+// RetryConfig controls how failed requests are retried.  Only failures that are likely to be transient, such as
+// network errors, server errors and rate limiting, are retried.  Zero values are replaced with the defaults from
+// DefaultRetryConfig.
+//
+// A request waiting for a retry is held until its next attempt, and is dropped as soon as it is abandoned, for
+// example when a later update of the record supersedes it.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	MaxAttempts     int
+	InitialInterval time.Duration
+	MaxInterval     time.Duration
+	// Multiplier is applied to the interval after each failed attempt.
+	Multiplier float64
+	// Jitter is the fraction, between 0 and 1, by which each interval is randomly increased or decreased.
+	Jitter float64
+	// MaxElapsedTime is the time after the first attempt after which the request is no longer retried.
+	MaxElapsedTime time.Duration
+}
+
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts:     11,
+		InitialInterval: 1 * time.Minute,
+		MaxInterval:     30 * time.Minute,
+		Multiplier:      2,
+		Jitter:          0.2,
+		MaxElapsedTime:  6 * time.Hour,
+	}
+}
+
+// PollConfig controls when records are refreshed.  Zero values are inherited: from PluginConfig.Poll, for
+// Domain.Poll, from Domain.Poll, for DnsRecord.Poll, and from DefaultPollConfig, for PluginConfig.Poll.
+//
+// Records are checked for a due refresh every 10 seconds, so times are effectively rounded up to that.
+type PollConfig struct {
+	// InitialDelay is the time after the plugin starts before the first refresh.
+	InitialDelay time.Duration
+	Interval     time.Duration
+	// FailureInterval is used instead of Interval after a refresh that failed, to try again sooner.
+	FailureInterval time.Duration
+	// Jitter is the fraction, between 0 and 1, by which each delay is randomly increased or decreased, so that
+	// refreshes of many records and assemblies are spread out.
+	Jitter float64
+	// Disabled stops the refreshes, for example, of records that are only written to.  Since overrides can only set
+	// it, polling of a domain or record can't be re-enabled by an override.
+	Disabled bool
+}
+
+func DefaultPollConfig() PollConfig {
+	return PollConfig{
+		InitialDelay:    20 * time.Second,
+		Interval:        4 * time.Hour,
+		FailureInterval: 15 * time.Minute,
+		Jitter:          0.1,
+	}
+}
+
+// WithOverride returns the config with the non-zero fields of override applied.  A nil override leaves the config
+// unchanged.
+func (c PollConfig) WithOverride(override *PollConfig) PollConfig {
+	if override == nil {
+		return c
+	}
+
+	if override.InitialDelay > 0 {
+		c.InitialDelay = override.InitialDelay
+	}
+
+	if override.Interval > 0 {
+		c.Interval = override.Interval
+	}
+
+	if override.FailureInterval > 0 {
+		c.FailureInterval = override.FailureInterval
+	}
+
+	if override.Jitter > 0 {
+		c.Jitter = override.Jitter
+	}
+
+	c.Disabled = c.Disabled || override.Disabled
+
+	return c
+}
+
+// RateLimitConfig limits the rate of API calls, shared by all the workers.  Calls are also paused for the time
+// requested by responses indicating that the API rate limit was exceeded.
+type RateLimitConfig struct {
+	// RequestsPerSecond is the average rate of calls.  Zero or less disables the limit.
+	RequestsPerSecond float64
+	// Burst is the number of calls that can be made at once after a quiet period.
+	Burst int
+}
+
+func DefaultRateLimitConfig() RateLimitConfig {
+	return RateLimitConfig{
+		RequestsPerSecond: 1,
+		Burst:             5,
+	}
+}
+
+// DefaultIpV4PingUrl is the ping endpoint on the IPv4-only API host, which always reports the IPv4 address.
+const DefaultIpV4PingUrl = "https://api-ipv4.porkbun.com/api/json/v3/ping"
+
+// PublicIpConfig controls the detection of the host's public IP addresses, for records with TrackPublicIp set.
+// Addresses are detected by calling the ping endpoint of the API, IpV4PingUrl, and the EchoUrls.
+type PublicIpConfig struct {
+	Interval time.Duration
+	// IpV4PingUrl is an additional ping endpoint, reachable only over IPv4.  Set to empty to skip.  The default,
+	// DefaultIpV4PingUrl, is skipped when ApiBaseUrl points at a stand-in for the Porkbun API, so the credentials
+	// aren't sent to the real API.
+	IpV4PingUrl string
+	// EchoUrls are additional URLs that respond to a GET request with the caller's address as plain text.
+	EchoUrls []string
+	// Timeout limits each call to a ping or echo URL.
+	Timeout time.Duration
+}
+
+func DefaultPublicIpConfig() PublicIpConfig {
+	return PublicIpConfig{
+		Interval:    5 * time.Minute,
+		IpV4PingUrl: DefaultIpV4PingUrl,
+		Timeout:     30 * time.Second,
+	}
+}
+
+// Validate checks all the configured DNS records, returning the errors of all invalid records.  The plugin panics
+// with this error in Init, so call Validate first to handle the error.
+func (c *PluginConfig) Validate() error {
+	errs := make([]error, 0)
+
+	for _, domain := range c.Domains {
+		for _, record := range domain.DnsRecords {
+			if err := record.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("%s: %w", domain.Name, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
 }
 
 func (c *PluginConfig) AddDomain(name string) *Domain {
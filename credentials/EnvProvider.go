@@ -0,0 +1,47 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultApiKeyEnvVar and DefaultApiSecretEnvVar are the environment variables read by an EnvProvider created
+// without variable names.
+const (
+	DefaultApiKeyEnvVar    = "PORKBUN_API_KEY"
+	DefaultApiSecretEnvVar = "PORKBUN_SECRET_API_KEY"
+)
+
+// EnvProvider reads the credentials from environment variables.  The variables are read on every call, so changes
+// made to the environment of the process are picked up.
+type EnvProvider struct {
+	apiKeyVar    string
+	apiSecretVar string
+}
+
+// NewEnvProvider returns a provider reading the passed environment variables.  Empty names are replaced with
+// DefaultApiKeyEnvVar and DefaultApiSecretEnvVar.
+func NewEnvProvider(apiKeyVar string, apiSecretVar string) *EnvProvider {
+	if apiKeyVar == "" {
+		apiKeyVar = DefaultApiKeyEnvVar
+	}
+
+	if apiSecretVar == "" {
+		apiSecretVar = DefaultApiSecretEnvVar
+	}
+
+	return &EnvProvider{apiKeyVar: apiKeyVar, apiSecretVar: apiSecretVar}
+}
+
+func (p *EnvProvider) Credentials() (Credentials, error) {
+	apiKey := strings.TrimSpace(os.Getenv(p.apiKeyVar))
+	apiSecret := strings.TrimSpace(os.Getenv(p.apiSecretVar))
+
+	if apiKey == "" || apiSecret == "" {
+		return Credentials{}, fmt.Errorf("environment variables %s and %s must both be set",
+			p.apiKeyVar, p.apiSecretVar)
+	}
+
+	return Credentials{ApiKey: apiKey, ApiSecret: apiSecret}, nil
+}
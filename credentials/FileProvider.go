@@ -0,0 +1,84 @@
+package credentials
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileProvider reads the key and the secret from two files, such as Docker or Kubernetes secrets, each holding just
+// the value.  The files are checked on every call, and read again when their modification time or size changed, so
+// rotated secrets are picked up.  Surrounding whitespace is ignored.
+type FileProvider struct {
+	mutex     sync.Mutex
+	apiKey    watchedFile
+	apiSecret watchedFile
+}
+
+// watchedFile caches the value of a file, along with the modification time and size it was read at.
+type watchedFile struct {
+	path    string
+	value   string
+	modTime time.Time
+	size    int64
+}
+
+func NewFileProvider(apiKeyPath string, apiSecretPath string) *FileProvider {
+	return &FileProvider{
+		apiKey:    watchedFile{path: apiKeyPath},
+		apiSecret: watchedFile{path: apiSecretPath},
+	}
+}
+
+func (p *FileProvider) Credentials() (Credentials, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	apiKey, err := p.apiKey.read()
+
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	apiSecret, err := p.apiSecret.read()
+
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	return Credentials{ApiKey: apiKey, ApiSecret: apiSecret}, nil
+}
+
+// read returns the value of the file, reading it again only if it changed since the last read.
+func (f *watchedFile) read() (string, error) {
+	// Stat follows symlinks, so the swap of the link to a new secret version, as done by Kubernetes, is detected
+	info, err := os.Stat(f.path)
+
+	if err != nil {
+		return "", fmt.Errorf("unable to read credentials file %s: %w", f.path, err)
+	}
+
+	if f.value != "" && info.ModTime().Equal(f.modTime) && info.Size() == f.size {
+		return f.value, nil
+	}
+
+	fileBytes, err := os.ReadFile(f.path)
+
+	if err != nil {
+		return "", fmt.Errorf("unable to read credentials file %s: %w", f.path, err)
+	}
+
+	value := strings.TrimSpace(string(fileBytes))
+
+	if value == "" {
+		return "", fmt.Errorf("credentials file %s is empty", f.path)
+	}
+
+	f.value = value
+	f.modTime = info.ModTime()
+	f.size = info.Size()
+
+	return value, nil
+}
@@ -0,0 +1,54 @@
+package credentials
+
+import "log/slog"
+
+// Redacted replaces the API key and secret wherever credentials are logged or formatted.
+const Redacted = "[REDACTED]"
+
+// Credentials are the API key and secret sent with each call to the Porkbun API.
+type Credentials struct {
+	ApiKey    string
+	ApiSecret string
+}
+
+// String keeps the key and secret out of messages formatted with %v and %s.
+func (c Credentials) String() string {
+	return "{ApiKey:" + Redacted + " ApiSecret:" + Redacted + "}"
+}
+
+// GoString keeps the key and secret out of messages formatted with %#v.
+func (c Credentials) GoString() string {
+	return "credentials.Credentials" + c.String()
+}
+
+// LogValue keeps the key and secret out of structured log entries.
+func (c Credentials) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("ApiKey", Redacted), slog.String("ApiSecret", Redacted))
+}
+
+// Provider supplies the credentials for API calls.  The plugin asks for the credentials before each call, from
+// multiple goroutines, so rotated credentials are used without restarting the plugin.  Implementations must be safe
+// for concurrent use, and should be cheap when the credentials haven't changed.
+type Provider interface {
+	Credentials() (Credentials, error)
+}
+
+// ProviderFunc adapts a callback to the Provider interface, e.g. to read the credentials from a secrets manager.
+type ProviderFunc func() (Credentials, error)
+
+func (f ProviderFunc) Credentials() (Credentials, error) {
+	return f()
+}
+
+// StaticProvider always returns the same credentials.
+type StaticProvider struct {
+	credentials Credentials
+}
+
+func NewStaticProvider(apiKey string, apiSecret string) *StaticProvider {
+	return &StaticProvider{credentials: Credentials{ApiKey: apiKey, ApiSecret: apiSecret}}
+}
+
+func (p *StaticProvider) Credentials() (Credentials, error) {
+	return p.credentials, nil
+}
@@ -0,0 +1,100 @@
+package credentials
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCredentialsAreRedacted(t *testing.T) {
+	credentials := Credentials{ApiKey: "pk1_key", ApiSecret: "sk1_secret"}
+	var logged bytes.Buffer
+	slog.New(slog.NewTextHandler(&logged, nil)).Info("test", "credentials", credentials)
+	formatted := []string{
+		fmt.Sprintf("%v", credentials),
+		fmt.Sprintf("%+v", credentials),
+		fmt.Sprintf("%#v", credentials),
+		fmt.Sprintf("%s", credentials),
+		logged.String(),
+	}
+
+	for _, output := range formatted {
+		if strings.Contains(output, "pk1_key") || strings.Contains(output, "sk1_secret") {
+			t.Errorf("got %q, want the key and secret redacted", output)
+		}
+	}
+}
+
+func TestEnvProvider(t *testing.T) {
+	t.Setenv(DefaultApiKeyEnvVar, " key ")
+	t.Setenv(DefaultApiSecretEnvVar, "secret\n")
+	credentials, err := NewEnvProvider("", "").Credentials()
+
+	if err != nil || credentials != (Credentials{ApiKey: "key", ApiSecret: "secret"}) {
+		t.Errorf("got %#v and error %v, want the trimmed values", credentials, err)
+	}
+
+	t.Setenv("TEST_PORKBUN_API_KEY", "key")
+
+	if _, err = NewEnvProvider("TEST_PORKBUN_API_KEY", "TEST_PORKBUN_MISSING").Credentials(); err == nil {
+		t.Error("got no error, want an error for a missing variable")
+	}
+}
+
+func TestFileProviderReadsChangedFiles(t *testing.T) {
+	directory := t.TempDir()
+	keyPath := filepath.Join(directory, "key")
+	secretPath := filepath.Join(directory, "secret")
+	writeFile(t, keyPath, "key1\n")
+	writeFile(t, secretPath, "secret1\n")
+	provider := NewFileProvider(keyPath, secretPath)
+	credentials, err := provider.Credentials()
+
+	if err != nil || credentials != (Credentials{ApiKey: "key1", ApiSecret: "secret1"}) {
+		t.Fatalf("got %#v and error %v, want the file contents", credentials, err)
+	}
+
+	// Move the modification time forward, since the change may land within the resolution of the file system
+	writeFile(t, secretPath, "secret2")
+	modTime := time.Now().Add(time.Minute)
+
+	if err = os.Chtimes(secretPath, modTime, modTime); err != nil {
+		t.Fatalf("unable to change the modification time: %v", err)
+	}
+
+	credentials, err = provider.Credentials()
+
+	if err != nil || credentials.ApiSecret != "secret2" {
+		t.Errorf("got %#v and error %v, want the rotated secret", credentials, err)
+	}
+}
+
+func TestFileProviderErrors(t *testing.T) {
+	directory := t.TempDir()
+	keyPath := filepath.Join(directory, "key")
+	secretPath := filepath.Join(directory, "secret")
+	writeFile(t, keyPath, "key")
+
+	if _, err := NewFileProvider(keyPath, secretPath).Credentials(); err == nil {
+		t.Error("got no error, want an error for a missing file")
+	}
+
+	writeFile(t, secretPath, " \n")
+
+	if _, err := NewFileProvider(keyPath, secretPath).Credentials(); err == nil {
+		t.Error("got no error, want an error for an empty file")
+	}
+}
+
+func writeFile(t *testing.T, path string, value string) {
+	t.Helper()
+
+	if err := os.WriteFile(path, []byte(value), 0600); err != nil {
+		t.Fatalf("unable to write %s: %v", path, err)
+	}
+}
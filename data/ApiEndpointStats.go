@@ -0,0 +1,20 @@
+package data
+
+import "time"
+
+// ApiEndpointStats reports the calls made to one endpoint of the Porkbun API.
+type ApiEndpointStats struct {
+	// Endpoint is the path of the endpoint, without the domain and record parameters, e.g. dns/retrieveByNameType
+	Endpoint string
+	// LatencyBuckets are the upper bounds of the latency histogram buckets
+	LatencyBuckets []time.Duration
+	// LatencyCounts are the cumulative number of calls that completed within each bucket
+	LatencyCounts []int
+	LatencySum    time.Duration
+	CallCount     int
+	// StatusCounts are the number of responses by HTTP status code
+	StatusCounts map[int]int
+	// TransportErrorCount is the number of calls that failed without a response
+	TransportErrorCount int
+	LastSuccessTime     time.Time
+}
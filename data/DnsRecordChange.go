@@ -0,0 +1,59 @@
+package data
+
+import (
+	"fmt"
+	"time"
+)
+
+// DnsRecordChangeSource identifies what made a change to a record.
+type DnsRecordChangeSource int
+
+const (
+	// DnsRecordChangeSourceCaller is an update or delete requested via the entity, for example, by UpdateValue
+	DnsRecordChangeSourceCaller DnsRecordChangeSource = iota
+	// DnsRecordChangeSourceReconciliation is an update that restored the configured state of the record
+	DnsRecordChangeSourceReconciliation
+	// DnsRecordChangeSourceExternal is a change made outside the plugin, found when the record was refreshed
+	DnsRecordChangeSourceExternal
+	// DnsRecordChangeSourceConfiguration is a create or delete required by the configuration of the record
+	DnsRecordChangeSourceConfiguration
+)
+
+func (s DnsRecordChangeSource) String() string {
+	switch s {
+	case DnsRecordChangeSourceReconciliation:
+		return "reconciliation"
+	case DnsRecordChangeSourceExternal:
+		return "external"
+	case DnsRecordChangeSourceConfiguration:
+		return "configuration"
+	default:
+		return "update"
+	}
+}
+
+func (s DnsRecordChangeSource) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *DnsRecordChangeSource) UnmarshalText(text []byte) error {
+	for _, source := range []DnsRecordChangeSource{DnsRecordChangeSourceCaller, DnsRecordChangeSourceReconciliation,
+		DnsRecordChangeSourceExternal, DnsRecordChangeSourceConfiguration} {
+		if source.String() == string(text) {
+			*s = source
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown DNS record change source \"%s\"", text)
+}
+
+// DnsRecordChange is an entry in the change history of a record.
+type DnsRecordChange struct {
+	Time   time.Time
+	Source DnsRecordChangeSource
+	// Old is the state before the change, or nil if the record was absent or its state was unknown
+	Old *DnsRecordSpec
+	// New is the state after the change, or nil if the record was deleted
+	New *DnsRecordSpec
+}
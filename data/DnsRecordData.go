@@ -1,21 +1,83 @@
 package data
 
-import "time"
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
 
 type DnsRecordData struct {
-	Id                 string
-	Name               string
-	Type               string
-	Value              string
-	Ttl                int32
-	Priority           int32
-	Notes              string
-	LastUpdateTime     time.Time
-	LastModifiedTime   time.Time
-	GetSuccessCount    int
-	GetErrorCount      int
-	UpdateSuccessCount int
-	UpdateErrorCount   int
-	LastError          error
-	LastErrorTime      time.Time
+	Id                    string
+	Domain                string
+	Name                  string
+	Type                  string
+	Value                 string
+	Ttl                   int32
+	Priority              int32
+	Notes                 string
+	Absent                bool
+	Discovered            bool
+	LastUpdateTime        time.Time
+	LastModifiedTime      time.Time
+	NextRefreshTime       time.Time
+	GetSuccessCount       int
+	GetErrorCount         int
+	UpdateSuccessCount    int
+	UpdateErrorCount      int
+	UpdateSupersededCount int
+	DeleteSuccessCount    int
+	DeleteErrorCount      int
+	ReconcileCount        int
+	LastReconcileTime     time.Time
+	DriftCount            int
+	LastDrift             *DnsRecordDrift
+	LastError             error `json:"-"`
+	LastErrorTime         time.Time
+	// DesiredValue is the value of the latest update of the record made by the plugin, or empty if there was none
+	DesiredValue string
+	// ValueMismatch is true when the value of the record is known to differ from DesiredValue, or the record exists
+	// but must be absent
+	ValueMismatch bool
+}
+
+// MarshalJSON serializes LastError as its message.
+func (d DnsRecordData) MarshalJSON() ([]byte, error) {
+	type plainDnsRecordData DnsRecordData
+
+	return json.Marshal(struct {
+		plainDnsRecordData
+		LastError string `json:",omitempty"`
+	}{
+		plainDnsRecordData: plainDnsRecordData(d),
+		LastError:          errorMessage(d.LastError),
+	})
+}
+
+// UnmarshalJSON restores LastError from its message.
+func (d *DnsRecordData) UnmarshalJSON(jsonBytes []byte) error {
+	type plainDnsRecordData DnsRecordData
+	var value struct {
+		plainDnsRecordData
+		LastError string
+	}
+
+	if err := json.Unmarshal(jsonBytes, &value); err != nil {
+		return err
+	}
+
+	*d = DnsRecordData(value.plainDnsRecordData)
+
+	if value.LastError != "" {
+		d.LastError = errors.New(value.LastError)
+	}
+
+	return nil
+}
+
+func errorMessage(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
 }
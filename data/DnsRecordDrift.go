@@ -0,0 +1,12 @@
+package data
+
+import "time"
+
+// DnsRecordDrift describes a change made to a record outside the plugin, detected when the record was refreshed.
+type DnsRecordDrift struct {
+	DetectionTime time.Time
+	// Expected is the state last written by the plugin
+	Expected DnsRecordSpec
+	// Actual is the state found by the refresh
+	Actual DnsRecordSpec
+}
@@ -0,0 +1,10 @@
+package data
+
+// DnsRecordSpec is the desired state of a DNS record.  A Ttl of zero leaves the TTL of an existing record unchanged,
+// and lets the API apply its default to a new record.
+type DnsRecordSpec struct {
+	Value    string
+	Ttl      int32
+	Priority int32
+	Notes    string
+}
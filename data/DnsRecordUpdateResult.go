@@ -0,0 +1,75 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// DnsRecordUpdateOutcome describes how an update request was resolved.
+type DnsRecordUpdateOutcome int
+
+const (
+	// DnsRecordUpdateFailed means the update failed after all retries, or was abandoned
+	DnsRecordUpdateFailed DnsRecordUpdateOutcome = iota
+	// DnsRecordUpdateUpdated means the record was changed
+	DnsRecordUpdateUpdated
+	// DnsRecordUpdateUnchanged means the record already had the requested state
+	DnsRecordUpdateUnchanged
+	// DnsRecordUpdateCreated means the record was missing and was created with the requested state
+	DnsRecordUpdateCreated
+	// DnsRecordUpdateSuperseded means the update was abandoned in favor of a later update of the same record
+	DnsRecordUpdateSuperseded
+)
+
+func (o DnsRecordUpdateOutcome) String() string {
+	switch o {
+	case DnsRecordUpdateUpdated:
+		return "updated"
+	case DnsRecordUpdateUnchanged:
+		return "unchanged"
+	case DnsRecordUpdateCreated:
+		return "created"
+	case DnsRecordUpdateSuperseded:
+		return "superseded"
+	default:
+		return "failed"
+	}
+}
+
+func (o DnsRecordUpdateOutcome) MarshalText() ([]byte, error) {
+	return []byte(o.String()), nil
+}
+
+func (o *DnsRecordUpdateOutcome) UnmarshalText(text []byte) error {
+	for _, outcome := range []DnsRecordUpdateOutcome{DnsRecordUpdateFailed, DnsRecordUpdateUpdated,
+		DnsRecordUpdateUnchanged, DnsRecordUpdateCreated, DnsRecordUpdateSuperseded} {
+		if outcome.String() == string(text) {
+			*o = outcome
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown DNS record update outcome \"%s\"", text)
+}
+
+// DnsRecordUpdateResult is the final result of an update, after all retries.
+type DnsRecordUpdateResult struct {
+	Outcome DnsRecordUpdateOutcome
+	Message string
+	// Data is the state of the record once the update was resolved
+	Data  DnsRecordData
+	Error error `json:"-"`
+}
+
+// MarshalJSON serializes Error as its message.
+func (r DnsRecordUpdateResult) MarshalJSON() ([]byte, error) {
+	type plainDnsRecordUpdateResult DnsRecordUpdateResult
+
+	return json.Marshal(struct {
+		plainDnsRecordUpdateResult
+		Error string `json:",omitempty"`
+	}{
+		plainDnsRecordUpdateResult: plainDnsRecordUpdateResult(r),
+		Error:                      errorMessage(r.Error),
+	})
+}
@@ -0,0 +1,15 @@
+package data
+
+import "time"
+
+// DualStackRecordData reports the state of a pair of A and AAAA records that are updated together from the addresses
+// of a host.
+type DualStackRecordData struct {
+	Domain string
+	Name   string
+	// IpV4 and IpV6 are the values of the A and AAAA records, or empty while the record is absent or not retrieved
+	IpV4 string
+	IpV6 string
+	// LastUpdateTime is the latest update time of the two records
+	LastUpdateTime time.Time
+}
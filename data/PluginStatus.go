@@ -11,8 +11,20 @@ type PluginStatus struct {
 	PeakRetryQueueSizeTime time.Time
 	PeakFailedAttempts     int
 	PeakFailedAttemptsTime time.Time
+	ThrottledCount         int
+	ThrottledTime          time.Duration
+	RateLimitedCount       int
+	LastRateLimitedTime    time.Time
+	RateLimitedUntil       time.Time
 	TotalSuccessCount      int
 	TotalErrorCount        int
+	TotalReconcileCount    int
 	LastErrorMessage       string
 	LastErrorTime          time.Time
+	PublicIpV4             string
+	PublicIpV6             string
+	PublicIpDetectionTime  time.Time
+	PublicIpErrorMessage   string
+	ApiEndpoints           []ApiEndpointStats
+	DualStackRecords       []DualStackRecordData
 }
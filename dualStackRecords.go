@@ -0,0 +1,69 @@
+package porkbun
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/avanha/pmaas-plugin-porkbun/config"
+	"github.com/avanha/pmaas-plugin-porkbun/data"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/dnsRecord"
+)
+
+// dualStackRecord is a configured pair of A and AAAA records, shown together on the status page.
+type dualStackRecord struct {
+	domain string
+	name   string
+	a      *dnsRecord.DnsRecord
+	aaaa   *dnsRecord.DnsRecord
+}
+
+// addDualStackRecords adds the dual-stack groups of the domain whose records were both configured.  Must be called
+// after the records of the domain were processed.
+func (p *plugin) addDualStackRecords(configuredDomain *config.Domain) {
+	for _, configuredGroup := range configuredDomain.DualStackRecords {
+		a, aOk := p.dnsRecords[dnsRecordKey(configuredDomain.Name, "A", configuredGroup.Name)]
+		aaaa, aaaaOk := p.dnsRecords[dnsRecordKey(configuredDomain.Name, "AAAA", configuredGroup.Name)]
+
+		if !aOk || !aaaaOk {
+			continue
+		}
+
+		p.dualStackRecords = append(p.dualStackRecords,
+			dualStackRecord{domain: configuredDomain.Name, name: configuredGroup.Name, a: a, aaaa: aaaa})
+	}
+
+	slices.SortFunc(p.dualStackRecords, func(x, y dualStackRecord) int {
+		return cmp.Or(cmp.Compare(x.domain, y.domain), cmp.Compare(x.name, y.name))
+	})
+}
+
+// dualStackRecordDatas returns the state of the dual-stack groups.  Must be called from the main plugin goroutine.
+func (p *plugin) dualStackRecordDatas() []data.DualStackRecordData {
+	datas := make([]data.DualStackRecordData, len(p.dualStackRecords))
+
+	for i, group := range p.dualStackRecords {
+		aData := group.a.Data()
+		aaaaData := group.aaaa.Data()
+		datas[i] = data.DualStackRecordData{
+			Domain:         group.domain,
+			Name:           group.name,
+			IpV4:           presentValue(&aData),
+			IpV6:           presentValue(&aaaaData),
+			LastUpdateTime: aData.LastUpdateTime,
+		}
+
+		if aaaaData.LastUpdateTime.After(aData.LastUpdateTime) {
+			datas[i].LastUpdateTime = aaaaData.LastUpdateTime
+		}
+	}
+
+	return datas
+}
+
+func presentValue(recordData *data.DnsRecordData) string {
+	if recordData.Absent {
+		return ""
+	}
+
+	return recordData.Value
+}
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"context"
 	"reflect"
 
 	"github.com/avanha/pmaas-plugin-porkbun/data"
@@ -9,7 +10,15 @@ import (
 type DnsRecord interface {
 	Name() string
 	UpdateValue(value string) error
+	// UpdateValueAndWait updates the value and blocks until the update is resolved, after all retries, or ctx is
+	// done.  The returned error is set when the update was not applied, either because it failed or because it was
+	// superseded by a later update.
+	UpdateValueAndWait(ctx context.Context, value string) (data.DnsRecordUpdateResult, error)
+	Update(spec data.DnsRecordSpec) error
+	Delete() error
 	Data() data.DnsRecordData
+	// History returns the changes made to the record, oldest first.  The number of changes kept is bounded.
+	History() []data.DnsRecordChange
 }
 
 var DnsRecordType = reflect.TypeOf((*DnsRecord)(nil)).Elem()
@@ -1,6 +1,9 @@
 package porkbun
 
 import (
+	"context"
+
+	"github.com/avanha/pmaas-plugin-porkbun/data"
 	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
 	"github.com/avanha/pmaas-spi"
 )
@@ -18,3 +21,98 @@ func (e entityStoreAdapter) GetStatusAndEntities() (common.StatusAndEntities, er
 		func() common.StatusAndEntities { return common.StatusAndEntities{} },
 		"unable to get status and entities")
 }
+
+func (e entityStoreAdapter) GetDnsRecordHistory(
+	domain string,
+	recordType string,
+	name string) (common.DnsRecordHistory, error) {
+	type historyResult struct {
+		history common.DnsRecordHistory
+		err     error
+	}
+
+	result, err := spi.ExecValueFunctionOnPluginGoRoutine(
+		e.parent.container,
+		func() historyResult {
+			history, err := e.parent.getDnsRecordHistory(domain, recordType, name)
+			return historyResult{history: history, err: err}
+		},
+		func() historyResult { return historyResult{} },
+		"unable to get DNS record history")
+
+	if err != nil {
+		return common.DnsRecordHistory{}, err
+	}
+
+	return result.history, result.err
+}
+
+func (e entityStoreAdapter) RefreshDnsRecord(domain string, recordType string, name string) error {
+	return e.execErrorFunction(
+		func() error { return e.parent.refreshDnsRecord(domain, recordType, name) },
+		"unable to refresh DNS record")
+}
+
+func (e entityStoreAdapter) RefreshAll() error {
+	return e.execErrorFunction(e.parent.refreshAll, "unable to refresh DNS records")
+}
+
+func (e entityStoreAdapter) UpdateDnsRecord(
+	ctx context.Context,
+	domain string,
+	recordType string,
+	name string,
+	patch common.DnsRecordPatch) (data.DnsRecordUpdateResult, error) {
+	type asyncUpdate struct {
+		resultCh <-chan data.DnsRecordUpdateResult
+		err      error
+	}
+
+	// Only start the update on the plugin goroutine; waiting there would block the processing of the result
+	update, err := spi.ExecValueFunctionOnPluginGoRoutine(
+		e.parent.container,
+		func() asyncUpdate {
+			// The update continues when the caller stops waiting
+			resultCh, err := e.parent.startDnsRecordUpdate(
+				context.Background(), domain, recordType, name, patch)
+			return asyncUpdate{resultCh: resultCh, err: err}
+		},
+		func() asyncUpdate { return asyncUpdate{} },
+		"unable to update DNS record")
+
+	if err == nil {
+		err = update.err
+	}
+
+	if err != nil {
+		return data.DnsRecordUpdateResult{Outcome: data.DnsRecordUpdateFailed, Error: err}, err
+	}
+
+	select {
+	case result := <-update.resultCh:
+		return result, result.Error
+	case <-ctx.Done():
+		return data.DnsRecordUpdateResult{Outcome: data.DnsRecordUpdateFailed, Error: ctx.Err()}, ctx.Err()
+	}
+}
+
+func (e entityStoreAdapter) DeleteDnsRecord(domain string, recordType string, name string) error {
+	return e.execErrorFunction(
+		func() error { return e.parent.deleteDnsRecord(domain, recordType, name) },
+		"unable to delete DNS record")
+}
+
+// execErrorFunction executes the passed function on the main plugin goroutine, returning its error.
+func (e entityStoreAdapter) execErrorFunction(f func() error, errorMessage string) error {
+	err, enqueueErr := spi.ExecValueFunctionOnPluginGoRoutine(
+		e.parent.container,
+		f,
+		func() error { return nil },
+		errorMessage)
+
+	if enqueueErr != nil {
+		return enqueueErr
+	}
+
+	return err
+}
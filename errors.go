@@ -0,0 +1,30 @@
+package porkbun
+
+import "github.com/avanha/pmaas-plugin-porkbun/internal/common"
+
+// APIError is the error returned, possibly wrapped, when the Porkbun API responds with an error.  Use errors.As to
+// retrieve it from DnsRecordData.LastError or the errors returned by DNS record operations.
+type APIError = common.APIError
+
+// APIErrorKind classifies an APIError.
+type APIErrorKind = common.APIErrorKind
+
+const (
+	APIErrorKindOther         = common.APIErrorKindOther
+	APIErrorKindAuth          = common.APIErrorKindAuth
+	APIErrorKindInvalidDomain = common.APIErrorKindInvalidDomain
+	APIErrorKindNotFound      = common.APIErrorKindNotFound
+	APIErrorKindRateLimited   = common.APIErrorKindRateLimited
+	APIErrorKindServer        = common.APIErrorKindServer
+)
+
+// ErrInvalidDnsRecordUpdate is returned by the DNS record update methods when the value, TTL or priority isn't valid
+// for the record.
+var ErrInvalidDnsRecordUpdate = common.ErrInvalidDnsRecordUpdate
+
+// ErrDnsRecordReadOnly is returned by the update and delete methods of records discovered in a domain.
+var ErrDnsRecordReadOnly = common.ErrDnsRecordReadOnly
+
+// ErrUpdateSuperseded is returned by UpdateValueAndWait when the update was abandoned in favor of a later update of
+// the same record.
+var ErrUpdateSuperseded = common.ErrUpdateSuperseded
@@ -1,6 +1,7 @@
 package events
 
 import (
+	"github.com/avanha/pmaas-plugin-porkbun/data"
 	"github.com/avanha/pmaas-plugin-porkbun/entities"
 	"github.com/avanha/pmaas-spi/events"
 )
@@ -9,3 +10,42 @@ type DnsRecordEntityStubAvailableEvent struct {
 	events.EntityEvent
 	EntityStub entities.DnsRecord
 }
+
+// DnsRecordDriftDetectedEvent is broadcast when a refresh finds that a record watched for drift no longer matches the
+// state last written by the plugin.
+type DnsRecordDriftDetectedEvent struct {
+	events.EntityEvent
+	Drift data.DnsRecordDrift
+}
+
+// DnsRecordValueChangedEvent is broadcast when the value of a record changes, whether by an update made by the plugin,
+// or a change found by a refresh.
+type DnsRecordValueChangedEvent struct {
+	events.EntityEvent
+	OldValue string
+	NewValue string
+	Data     data.DnsRecordData
+}
+
+// DnsRecordUpdateFailedEvent is broadcast when an update fails, after all retries.
+type DnsRecordUpdateFailedEvent struct {
+	events.EntityEvent
+	Error error
+}
+
+// DnsRecordRetrievalFailedEvent is broadcast when a refresh fails, after all retries.
+type DnsRecordRetrievalFailedEvent struct {
+	events.EntityEvent
+	Error error
+}
+
+// DnsRecordCreatedEvent is broadcast when the plugin creates a missing record.
+type DnsRecordCreatedEvent struct {
+	events.EntityEvent
+	Data data.DnsRecordData
+}
+
+// DnsRecordDeletedEvent is broadcast when the plugin deletes a record.
+type DnsRecordDeletedEvent struct {
+	events.EntityEvent
+}
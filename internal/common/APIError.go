@@ -0,0 +1,89 @@
+package common
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type APIErrorKind int
+
+const (
+	APIErrorKindOther APIErrorKind = iota
+	APIErrorKindAuth
+	APIErrorKindInvalidDomain
+	APIErrorKindNotFound
+	APIErrorKindRateLimited
+	APIErrorKindServer
+)
+
+func (k APIErrorKind) String() string {
+	switch k {
+	case APIErrorKindAuth:
+		return "auth"
+	case APIErrorKindInvalidDomain:
+		return "invalid domain"
+	case APIErrorKindNotFound:
+		return "not found"
+	case APIErrorKindRateLimited:
+		return "rate limited"
+	case APIErrorKindServer:
+		return "server"
+	default:
+		return "other"
+	}
+}
+
+// APIError is returned when the Porkbun API responds with an error, either via the HTTP status code or via the
+// status field of the response body.
+type APIError struct {
+	// Status is the value of the status field in the response body, or empty if the body could not be parsed.
+	Status string
+	// Message is the error message in the response body.
+	Message        string
+	HttpStatusCode int
+	// Endpoint is the API path that was called, relative to the base URL, for example "dns/edit/example.com/123".
+	Endpoint string
+	// RetryAfter is the delay requested by the Retry-After header of the response, or zero if there was none.
+	RetryAfter time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("porkbun API error calling %s (HTTP %d, status %q): %s",
+		e.Endpoint, e.HttpStatusCode, e.Status, e.Message)
+}
+
+// Kind classifies the error based on the HTTP status code and the message returned by the API.
+func (e *APIError) Kind() APIErrorKind {
+	message := strings.ToLower(e.Message)
+
+	switch {
+	case e.HttpStatusCode == http.StatusTooManyRequests || strings.Contains(message, "rate limit"):
+		return APIErrorKindRateLimited
+	case e.HttpStatusCode == http.StatusUnauthorized || e.HttpStatusCode == http.StatusForbidden ||
+		strings.Contains(message, "api key") || strings.Contains(message, "api access"):
+		return APIErrorKindAuth
+	case strings.Contains(message, "invalid domain"):
+		return APIErrorKindInvalidDomain
+	case e.HttpStatusCode == http.StatusNotFound || strings.Contains(message, "invalid record id") ||
+		strings.Contains(message, "not found"):
+		return APIErrorKindNotFound
+	case e.HttpStatusCode >= 500:
+		return APIErrorKindServer
+	default:
+		return APIErrorKindOther
+	}
+}
+
+func (e *APIError) IsAuthError() bool {
+	return e.Kind() == APIErrorKindAuth
+}
+
+func (e *APIError) IsInvalidDomain() bool {
+	return e.Kind() == APIErrorKindInvalidDomain
+}
+
+func (e *APIError) IsRateLimited() bool {
+	return e.Kind() == APIErrorKindRateLimited
+}
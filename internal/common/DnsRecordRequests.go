@@ -6,16 +6,48 @@ type DnsRecordResult struct {
 	Error       error
 	Message     string
 	CurrentData data.DnsRecordData
+	// Created is set when the record was created because it was missing
+	Created bool
+	// Unchanged is set when the record already had the desired state, so no update was sent
+	Unchanged bool
+	// DomainRecords holds all the records of a domain, in response to a RetrieveDomainRequest
+	DomainRecords []data.DnsRecordData
+	// PublicIp is the address of the caller reported by the API, in response to a PingRequest
+	PublicIp string
 }
 
 type GetDnsRecordRequest struct {
-	Domain string
-	Type   string
-	Name   string
+	Domain          string
+	Type            string
+	Name            string
+	CreateIfMissing *data.DnsRecordSpec
 }
 
 type UpdateDnsRecordRequest struct {
 	Domain      string
 	CurrentData data.DnsRecordData
-	NewValue    string
+	Desired     data.DnsRecordSpec
+	// ValueOnly updates only the value, keeping the TTL, priority and notes of the record
+	ValueOnly       bool
+	CreateIfMissing *data.DnsRecordSpec
+}
+
+// DeleteDnsRecordRequest deletes a record by id if Id is set, otherwise, or if the id is no longer found, the records
+// with Type and Name.
+type DeleteDnsRecordRequest struct {
+	Domain string
+	Type   string
+	Name   string
+	Id     string
+}
+
+// RetrieveDomainRequest retrieves all records in a domain
+type RetrieveDomainRequest struct {
+	Domain string
+}
+
+// PingRequest calls a ping endpoint, which reports the address of the caller.  Url is the full URL of the endpoint,
+// since the IPv4-only ping endpoint is on a different host than the rest of the API.
+type PingRequest struct {
+	Url string
 }
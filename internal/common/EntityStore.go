@@ -1,6 +1,8 @@
 package common
 
 import (
+	"context"
+
 	"github.com/avanha/pmaas-plugin-porkbun/data"
 )
 
@@ -9,6 +11,35 @@ type StatusAndEntities struct {
 	DnsRecords []data.DnsRecordData
 }
 
+// DnsRecordHistory is the current data and the change history of a record.
+type DnsRecordHistory struct {
+	Data    data.DnsRecordData
+	Changes []data.DnsRecordChange
+}
+
+// DnsRecordPatch is a partial update of a record.  Nil fields keep their current value.
+type DnsRecordPatch struct {
+	Value    *string
+	Ttl      *int32
+	Priority *int32
+	Notes    *string
+}
+
 type EntityStore interface {
 	GetStatusAndEntities() (StatusAndEntities, error)
+	GetDnsRecordHistory(domain string, recordType string, name string) (DnsRecordHistory, error)
+	// RefreshDnsRecord refreshes a record, regardless of its poll schedule.
+	RefreshDnsRecord(domain string, recordType string, name string) error
+	// RefreshAll refreshes all records, regardless of their poll schedule.
+	RefreshAll() error
+	// UpdateDnsRecord applies a patch to a record, and waits for the result until ctx is done.  The update continues
+	// after that.
+	UpdateDnsRecord(
+		ctx context.Context,
+		domain string,
+		recordType string,
+		name string,
+		patch DnsRecordPatch) (data.DnsRecordUpdateResult, error)
+	// DeleteDnsRecord deletes a record from its domain.  The result is reported through the record's data.
+	DeleteDnsRecord(domain string, recordType string, name string) error
 }
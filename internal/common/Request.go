@@ -1,15 +1,99 @@
 package common
 
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+// ErrDnsRecordNotFound is returned when the requested DNS record does not exist.
+var ErrDnsRecordNotFound = errors.New("no DNS records found")
+
+// ErrInvalidDnsRecordUpdate is returned for updates with a value, TTL or priority that isn't valid for the record.
+var ErrInvalidDnsRecordUpdate = errors.New("invalid DNS record update")
+
+// ErrDnsRecordReadOnly is returned for changes to records discovered in a domain, which are read-only.
+var ErrDnsRecordReadOnly = errors.New("discovered DNS records are read-only")
+
+// ErrUpdateSuperseded is the cause of update requests abandoned in favor of a later update of the same record.
+var ErrUpdateSuperseded = errors.New("superseded by a later update")
+
 const (
 	RequestTypeGetDnsRecord    = 1
 	RequestTypeUpdateDnsRecord = 2
+	RequestTypeDeleteDnsRecord = 3
+	RequestTypeRetrieveDomain  = 4
+	RequestTypePing            = 5
 )
 
+// RequestTypeName returns the name of the request type, for logging.
+func RequestTypeName(requestType int) string {
+	switch requestType {
+	case RequestTypeGetDnsRecord:
+		return "get"
+	case RequestTypeUpdateDnsRecord:
+		return "update"
+	case RequestTypeDeleteDnsRecord:
+		return "delete"
+	case RequestTypeRetrieveDomain:
+		return "retrieve_domain"
+	case RequestTypePing:
+		return "ping"
+	}
+
+	return strconv.Itoa(requestType)
+}
+
 type Request struct {
 	RequestType            int
 	ResultCh               chan DnsRecordResult
 	GetDnsRecordRequest    GetDnsRecordRequest
 	UpdateDnsRecordRequest UpdateDnsRecordRequest
+	DeleteDnsRecordRequest DeleteDnsRecordRequest
+	RetrieveDomainRequest  RetrieveDomainRequest
+	PingRequest            PingRequest
+	Retry                  RetryState
+	// Context, when set, abandons the request once it is canceled, instead of sending or retrying it
+	Context context.Context
+}
+
+// LogArgs returns the attributes that identify the request and its record in log entries.
+func (r *Request) LogArgs() []any {
+	args := []any{"request_type", RequestTypeName(r.RequestType), "attempt", r.Retry.FailedAttempts + 1}
+
+	switch r.RequestType {
+	case RequestTypeGetDnsRecord:
+		args = append(args,
+			"domain", r.GetDnsRecordRequest.Domain,
+			"type", r.GetDnsRecordRequest.Type,
+			"name", r.GetDnsRecordRequest.Name)
+	case RequestTypeUpdateDnsRecord:
+		args = append(args,
+			"domain", r.UpdateDnsRecordRequest.Domain,
+			"type", r.UpdateDnsRecordRequest.CurrentData.Type,
+			"name", r.UpdateDnsRecordRequest.CurrentData.Name,
+			"record_id", r.UpdateDnsRecordRequest.CurrentData.Id)
+	case RequestTypeDeleteDnsRecord:
+		args = append(args,
+			"domain", r.DeleteDnsRecordRequest.Domain,
+			"type", r.DeleteDnsRecordRequest.Type,
+			"name", r.DeleteDnsRecordRequest.Name,
+			"record_id", r.DeleteDnsRecordRequest.Id)
+	case RequestTypeRetrieveDomain:
+		args = append(args, "domain", r.RetrieveDomainRequest.Domain)
+	case RequestTypePing:
+		args = append(args, "url", r.PingRequest.Url)
+	}
+
+	return args
+}
+
+// RetryState tracks the attempts of a request that is retried by the retrying queue.
+type RetryState struct {
+	FirstAttemptTime time.Time
+	FailedAttempts   int
+	NextAttemptTime  time.Time
 }
 
 type Response struct {
@@ -1,15 +1,24 @@
 package dnsRecord
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"slices"
 	"time"
 
+	"github.com/avanha/pmaas-plugin-porkbun/config"
 	"github.com/avanha/pmaas-plugin-porkbun/data"
 	"github.com/avanha/pmaas-plugin-porkbun/entities"
 	"github.com/avanha/pmaas-plugin-porkbun/events"
 	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/poll"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/validation"
+	"github.com/avanha/pmaas-plugin-porkbun/state"
 	"github.com/avanha/pmaas-spi"
 	spicommon "github.com/avanha/pmaas-spi/common"
+	spievents "github.com/avanha/pmaas-spi/events"
 )
 
 type DnsRecord struct {
@@ -18,9 +27,55 @@ type DnsRecord struct {
 	pmaasEntityId                  string
 	domain                         string
 	currentData                    data.DnsRecordData
+	createSpec                     *data.DnsRecordSpec
+	mustBeAbsent                   bool
+	reconcile                      bool
+	watchDrift                     bool
+	lastWritten                    *data.DnsRecordSpec
+	discovered                     bool
 	onEntityStubAvailableListeners []func(event events.DnsRecordEntityStubAvailableEvent)
+	eventListeners                 []func(event any)
 	stub                           *DnsRecordStub
 	requestHandlerFn               func(request common.Request) error
+	updateInFlight                 bool
+	cancelInFlightUpdateFn         context.CancelCauseFunc
+	pendingUpdate                  *pendingUpdate
+	pollSchedule                   *poll.Schedule
+	onChangedFn                    func()
+	history                        []data.DnsRecordChange
+	logger                         *slog.Logger
+}
+
+// maxHistoryLength is the number of changes kept in the history of a record.
+const maxHistoryLength = 100
+
+// pendingUpdate is an update that waits for the update in flight to complete, so updates of a record are applied in
+// order.  Only the latest one is kept.
+type pendingUpdate struct {
+	ctx       context.Context
+	spec      data.DnsRecordSpec
+	valueOnly bool
+	source    data.DnsRecordChangeSource
+	waiterCh  chan<- data.DnsRecordUpdateResult
+}
+
+// Options holds the configured behavior of a record.
+type Options struct {
+	// CreateSpec, if set, is used to create the record if it does not exist.
+	CreateSpec   *data.DnsRecordSpec
+	MustBeAbsent bool
+	// Reconcile makes CreateSpec the desired state of the record.
+	Reconcile  bool
+	WatchDrift bool
+	// EventListeners receive the runtime events of the record on the server goroutine.
+	EventListeners []func(event any)
+	// Poll is the schedule on which the record is refreshed.
+	Poll config.PollConfig
+	// OnChanged, if set, is called on the plugin goroutine after the data of the record changed.
+	OnChanged func()
+	// Logger receives the log entries of the record, which carry its domain, type and name.  Defaults to
+	// slog.Default().
+	Logger *slog.Logger
 }
 
 func NewDnsRecord(
@@ -29,19 +84,68 @@ func NewDnsRecord(
 	domain string,
 	recordType string,
 	name string,
+	options Options,
 	requestHandlerFn func(request common.Request) error,
 	onEntityStubAvailableListeners []func(event events.DnsRecordEntityStubAvailableEvent)) *DnsRecord {
-	return &DnsRecord{
+	record := &DnsRecord{
 		container: container,
 		id:        id,
 		domain:    domain,
 		currentData: data.DnsRecordData{
-			Name: name,
-			Type: recordType,
+			Domain: domain,
+			Name:   name,
+			Type:   recordType,
 		},
+		createSpec:                     options.CreateSpec,
+		mustBeAbsent:                   options.MustBeAbsent,
+		reconcile:                      options.Reconcile && options.CreateSpec != nil,
+		watchDrift:                     options.WatchDrift,
 		requestHandlerFn:               requestHandlerFn,
 		onEntityStubAvailableListeners: onEntityStubAvailableListeners,
+		eventListeners:                 options.EventListeners,
+		pollSchedule:                   poll.NewSchedule(options.Poll),
+		onChangedFn:                    options.OnChanged,
+		logger:                         recordLogger(options.Logger, domain, recordType, name),
+	}
+
+	if record.reconcile {
+		record.currentData.DesiredValue = options.CreateSpec.Value
+	}
+
+	return record
+}
+
+// NewDiscoveredDnsRecord creates a read-only record for a record found by retrieving the whole domain.  Discovered
+// records are not refreshed individually, they're updated via ApplyDiscoveredData instead.
+func NewDiscoveredDnsRecord(
+	container spi.IPMAASContainer,
+	id string,
+	domain string,
+	recordData *data.DnsRecordData,
+	logger *slog.Logger) *DnsRecord {
+	record := &DnsRecord{
+		container:  container,
+		id:         id,
+		domain:     domain,
+		discovered: true,
+		logger:     recordLogger(logger, domain, recordData.Type, recordData.Name),
+		requestHandlerFn: func(_ common.Request) error {
+			return common.ErrDnsRecordReadOnly
+		},
 	}
+	record.currentData.Domain = domain
+	record.currentData.Discovered = true
+	record.ApplyDiscoveredData(recordData)
+
+	return record
+}
+
+func recordLogger(logger *slog.Logger, domain string, recordType string, name string) *slog.Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return logger.With("domain", domain, "type", recordType, "name", name)
 }
 
 func (r *DnsRecord) Id() string {
@@ -53,44 +157,507 @@ func (r *DnsRecord) Name() string {
 }
 
 func (r *DnsRecord) Data() data.DnsRecordData {
-	return r.currentData
+	recordData := r.currentData
+
+	if r.pollSchedule != nil {
+		recordData.NextRefreshTime = r.pollSchedule.NextTime()
+	}
+
+	recordData.ValueMismatch = r.valueMismatch()
+
+	return recordData
+}
+
+// valueMismatch returns true if the record isn't in the state the plugin last tried to put it in, e.g. because the
+// update failed or the record was changed outside the plugin.
+func (r *DnsRecord) valueMismatch() bool {
+	if r.mustBeAbsent {
+		observed, _ := r.observedSpec()
+		return observed && !r.currentData.Absent
+	}
+
+	return r.currentData.DesiredValue != "" &&
+		(r.currentData.Absent || r.currentData.Value != r.currentData.DesiredValue)
+}
+
+// State returns the state of the record to save across restarts.
+func (r *DnsRecord) State() state.DnsRecordState {
+	recordState := state.DnsRecordState{
+		Domain:      r.domain,
+		Type:        r.currentData.Type,
+		Name:        r.currentData.Name,
+		Data:        r.currentData,
+		LastWritten: r.lastWritten,
+		History:     r.history,
+	}
+
+	return recordState
+}
+
+// RestoreState restores the state saved by a previous run of the plugin.  The configured name and type are kept.
+func (r *DnsRecord) RestoreState(recordState *state.DnsRecordState) {
+	name := r.currentData.Name
+	recordType := r.currentData.Type
+	desiredValue := r.currentData.DesiredValue
+	r.currentData = recordState.Data
+	r.currentData.Domain = r.domain
+	r.currentData.Name = name
+	r.currentData.Type = recordType
+	r.currentData.NextRefreshTime = time.Time{}
+
+	// The configured value of a reconciled record takes precedence
+	if desiredValue != "" {
+		r.currentData.DesiredValue = desiredValue
+	}
+
+	r.lastWritten = recordState.LastWritten
+	r.history = recordState.History
+}
+
+// changed notifies the OnChanged listener that the data of the record changed.
+func (r *DnsRecord) changed() {
+	if r.onChangedFn != nil {
+		r.onChangedFn()
+	}
+}
+
+func (r *DnsRecord) Domain() string {
+	return r.domain
+}
+
+// Discovered returns true if the record was created from the records found in the domain, rather than from
+// configuration.
+func (r *DnsRecord) Discovered() bool {
+	return r.discovered
+}
+
+// ApplyDiscoveredData updates the record with data retrieved as part of the whole domain.
+func (r *DnsRecord) ApplyDiscoveredData(recordData *data.DnsRecordData) {
+	if r.currentData.Value != "" && r.currentData.Value != recordData.Value {
+		r.currentData.LastModifiedTime = recordData.LastUpdateTime
+	}
+
+	observed, oldSpec := r.observedSpec()
+	r.updateData(recordData)
+	r.currentData.GetSuccessCount++
+
+	if observed {
+		r.recordChange(data.DnsRecordChangeSourceExternal, oldSpec)
+	}
+}
+
+// History returns the changes made to the record, oldest first.
+func (r *DnsRecord) History() []data.DnsRecordChange {
+	return slices.Clone(r.history)
+}
+
+// observedSpec returns whether the record was ever observed, and its state, or nil if it is absent.
+func (r *DnsRecord) observedSpec() (bool, *data.DnsRecordSpec) {
+	if r.currentData.LastUpdateTime.IsZero() && r.currentData.LastModifiedTime.IsZero() {
+		return false, nil
+	}
+
+	if r.currentData.Absent {
+		return true, nil
+	}
+
+	spec := specOf(&r.currentData)
+
+	return true, &spec
+}
+
+// recordChange adds an entry to the history of the record, if its state differs from oldSpec.
+func (r *DnsRecord) recordChange(source data.DnsRecordChangeSource, oldSpec *data.DnsRecordSpec) {
+	_, newSpec := r.observedSpec()
+
+	if oldSpec == nil && newSpec == nil || oldSpec != nil && newSpec != nil && *oldSpec == *newSpec {
+		return
+	}
+
+	r.appendChange(source, oldSpec, newSpec)
+}
+
+func (r *DnsRecord) appendChange(source data.DnsRecordChangeSource, oldSpec, newSpec *data.DnsRecordSpec) {
+	r.history = append(r.history, data.DnsRecordChange{
+		Time:   time.Now(),
+		Source: source,
+		Old:    oldSpec,
+		New:    newSpec,
+	})
+
+	if len(r.history) > maxHistoryLength {
+		r.history = slices.Delete(r.history, 0, len(r.history)-maxHistoryLength)
+	}
 }
 
 func (r *DnsRecord) UpdateValue(value string) error {
-	fmt.Printf("Received request to update DNS record %s to value %s\n", r.currentData.Name, value)
+	r.logger.Debug("Received request to update DNS record", "value", value)
+
+	if err := validation.ValidateContent(r.currentData.Type, value); err != nil {
+		return r.invalidUpdateError(err)
+	}
+
+	return r.enqueueUpdate(
+		context.Background(), data.DnsRecordSpec{Value: value}, true, data.DnsRecordChangeSourceCaller, nil)
+}
+
+// UpdateValueAsync updates the value of the record, returning a channel that receives the final result of the update,
+// after all retries.  The update is abandoned once ctx is done.
+func (r *DnsRecord) UpdateValueAsync(ctx context.Context, value string) (<-chan data.DnsRecordUpdateResult, error) {
+	r.logger.Debug("Received request to update DNS record and wait", "value", value)
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("unable to update DNS record %s: %w", r.currentData.Name, err)
+	}
+
+	if err := validation.ValidateContent(r.currentData.Type, value); err != nil {
+		return nil, r.invalidUpdateError(err)
+	}
+
+	// Buffered, so the result can be delivered even if the caller stopped waiting
+	waiterCh := make(chan data.DnsRecordUpdateResult, 1)
+	err := r.enqueueUpdate(ctx, data.DnsRecordSpec{Value: value}, true, data.DnsRecordChangeSourceCaller, waiterCh)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return waiterCh, nil
+}
+
+// Update sets the value, TTL, priority and notes of the record in a single call.
+func (r *DnsRecord) Update(spec data.DnsRecordSpec) error {
+	r.logger.Debug("Received request to update DNS record", "spec", spec)
+
+	err := errors.Join(
+		validation.ValidateContent(r.currentData.Type, spec.Value),
+		validation.ValidateTtl(spec.Ttl),
+		validation.ValidatePriority(spec.Priority))
+
+	if err != nil {
+		return r.invalidUpdateError(err)
+	}
+
+	return r.enqueueUpdate(context.Background(), spec, false, data.DnsRecordChangeSourceCaller, nil)
+}
+
+// invalidUpdateError wraps the validation errors of an update, so they can be told apart from failed updates.
+func (r *DnsRecord) invalidUpdateError(err error) error {
+	return fmt.Errorf("unable to update DNS record %s: %w: %w",
+		r.currentData.Name, common.ErrInvalidDnsRecordUpdate, err)
+}
+
+// UpdateAsync sets the value, TTL, priority and notes of the record, returning a channel that receives the final
+// result of the update, after all retries.  The update is abandoned once ctx is done.
+func (r *DnsRecord) UpdateAsync(ctx context.Context, spec data.DnsRecordSpec) (<-chan data.DnsRecordUpdateResult, error) {
+	r.logger.Debug("Received request to update DNS record and wait", "spec", spec)
+
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("unable to update DNS record %s: %w", r.currentData.Name, err)
+	}
+
+	err := errors.Join(
+		validation.ValidateContent(r.currentData.Type, spec.Value),
+		validation.ValidateTtl(spec.Ttl),
+		validation.ValidatePriority(spec.Priority))
+
+	if err != nil {
+		return nil, r.invalidUpdateError(err)
+	}
+
+	// Buffered, so the result can be delivered even if the caller stopped waiting
+	waiterCh := make(chan data.DnsRecordUpdateResult, 1)
+	err = r.enqueueUpdate(ctx, spec, false, data.DnsRecordChangeSourceCaller, waiterCh)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return waiterCh, nil
+}
+
+// enqueueUpdate sends the update request to the worker.  When waiterCh is set, it receives the result once it has
+// been applied to the record.  While another update of the record is in flight, the update is kept pending instead,
+// superseding any earlier pending update, and the update in flight is abandoned unless it is already being sent.
+func (r *DnsRecord) enqueueUpdate(
+	ctx context.Context,
+	spec data.DnsRecordSpec,
+	valueOnly bool,
+	source data.DnsRecordChangeSource,
+	waiterCh chan<- data.DnsRecordUpdateResult) error {
+	update := &pendingUpdate{
+		ctx:       ctx,
+		spec:      spec,
+		valueOnly: valueOnly,
+		source:    source,
+		waiterCh:  waiterCh,
+	}
+
+	if !r.updateInFlight {
+		return r.sendUpdate(update)
+	}
+
+	if r.pendingUpdate != nil {
+		r.completeSupersededUpdate(r.pendingUpdate)
+	}
+
+	r.logger.Debug("DNS record has an update in flight, update pending", "spec", spec)
+	r.pendingUpdate = update
+	r.cancelInFlightUpdateFn(common.ErrUpdateSuperseded)
+
+	return nil
+}
+
+func (r *DnsRecord) sendUpdate(update *pendingUpdate) error {
+	ctx, cancelFn := context.WithCancelCause(update.ctx)
 	resultCh := make(chan common.DnsRecordResult)
 	request := common.Request{
 		RequestType: common.RequestTypeUpdateDnsRecord,
 		ResultCh:    resultCh,
 		UpdateDnsRecordRequest: common.UpdateDnsRecordRequest{
-			Domain:      r.domain,
-			CurrentData: r.currentData,
-			NewValue:    value,
+			Domain:          r.domain,
+			CurrentData:     r.currentData,
+			Desired:         update.spec,
+			ValueOnly:       update.valueOnly,
+			CreateIfMissing: r.createSpec,
 		},
+		Context: ctx,
 	}
 
 	err := r.requestHandlerFn(request)
 
 	if err != nil {
-		return fmt.Errorf("failed to enqueue DNS record %s update: %v", r.currentData.Name, err)
+		cancelFn(nil)
+		return fmt.Errorf("failed to enqueue DNS record %s update: %w", r.currentData.Name, err)
 	}
 
-	go readAndProcessResult(r, resultCh, r.processUpdateValueResult, "update DNS record")
+	// Only once the update is on its way, so values that were never sent aren't reported as mismatched
+	r.currentData.DesiredValue = update.spec.Value
+	r.updateInFlight = true
+	r.cancelInFlightUpdateFn = cancelFn
+	go r.readAndProcessUpdateResult(update.ctx, resultCh, update.source, update.waiterCh)
 
 	return nil
 }
 
-func (r *DnsRecord) processUpdateValueResult(result common.DnsRecordResult) {
+func (r *DnsRecord) readAndProcessUpdateResult(
+	ctx context.Context,
+	resultCh <-chan common.DnsRecordResult,
+	source data.DnsRecordChangeSource,
+	waiterCh chan<- data.DnsRecordUpdateResult) {
+	result := <-resultCh
+	err := r.container.EnqueueOnPluginGoRoutine(func() {
+		updateResult := r.processUpdateValueResult(ctx, result, source)
+		r.changed()
+
+		if waiterCh != nil {
+			waiterCh <- updateResult
+		}
+
+		r.sendPendingUpdate()
+	})
+
+	if err != nil {
+		r.logger.Error("Error processing update DNS record result", "error", err)
+		err = fmt.Errorf("unable to process DNS record update result: %w", err)
+
+		if waiterCh != nil {
+			waiterCh <- data.DnsRecordUpdateResult{Outcome: data.DnsRecordUpdateFailed, Error: err}
+		}
+
+		// The plugin goroutine no longer runs functions, so clear the update in flight here, rather than blocking
+		// the updates of the record forever
+		update := r.completeInFlightUpdate()
+
+		if update != nil {
+			r.completeFailedUpdate(update, err)
+		}
+	}
+}
+
+// completeInFlightUpdate marks the update in flight as complete, and returns the pending update, if there is one.
+func (r *DnsRecord) completeInFlightUpdate() *pendingUpdate {
+	r.cancelInFlightUpdateFn(nil)
+	r.cancelInFlightUpdateFn = nil
+	r.updateInFlight = false
+	update := r.pendingUpdate
+	r.pendingUpdate = nil
+
+	return update
+}
+
+// sendPendingUpdate marks the update in flight as complete, and sends the pending update, if there is one.
+func (r *DnsRecord) sendPendingUpdate() {
+	update := r.completeInFlightUpdate()
+
+	if update == nil {
+		return
+	}
+
+	err := update.ctx.Err()
+
+	if err != nil {
+		r.logger.Info("Pending update of DNS record abandoned by the caller", "spec", update.spec, "error", err)
+	} else {
+		err = r.sendUpdate(update)
+
+		if err != nil {
+			r.logger.Error("Error sending pending update of DNS record", "error", err)
+		}
+	}
+
+	if err != nil {
+		r.completeFailedUpdate(update, err)
+	}
+}
+
+func (r *DnsRecord) completeFailedUpdate(update *pendingUpdate, err error) {
+	if update.waiterCh != nil {
+		update.waiterCh <- data.DnsRecordUpdateResult{
+			Outcome: data.DnsRecordUpdateFailed,
+			Data:    r.currentData,
+			Error:   err,
+		}
+	}
+}
+
+func (r *DnsRecord) completeSupersededUpdate(update *pendingUpdate) {
+	r.logger.Info("Pending update of DNS record superseded", "spec", update.spec)
+	r.currentData.UpdateSupersededCount++
+
+	if update.waiterCh != nil {
+		update.waiterCh <- data.DnsRecordUpdateResult{
+			Outcome: data.DnsRecordUpdateSuperseded,
+			Data:    r.currentData,
+			Error:   common.ErrUpdateSuperseded,
+		}
+	}
+}
+
+// processUpdateValueResult applies the result of an update to the record and returns the outcome.  ctx is the context
+// of the caller that requested the update.
+func (r *DnsRecord) processUpdateValueResult(
+	ctx context.Context,
+	result common.DnsRecordResult,
+	source data.DnsRecordChangeSource) data.DnsRecordUpdateResult {
 	if result.Error == nil {
-		fmt.Printf("Updated DNS record %s successfully: %s\n", r.currentData.Name, result.Message)
+		r.logger.Info("Updated DNS record successfully",
+			"record_id", result.CurrentData.Id, "value", result.CurrentData.Value, "message", result.Message)
+		oldValue := r.currentData.Value
+		_, oldSpec := r.observedSpec()
 		r.updateData(&result.CurrentData)
+		r.recordChange(source, oldSpec)
 		r.currentData.LastModifiedTime = result.CurrentData.LastModifiedTime
 		r.currentData.UpdateSuccessCount++
+		written := specOf(&r.currentData)
+		r.lastWritten = &written
+		r.emitChangeEvents(&result, oldValue)
+
+		outcome := data.DnsRecordUpdateUpdated
+
+		if result.Created {
+			outcome = data.DnsRecordUpdateCreated
+		} else if result.Unchanged {
+			outcome = data.DnsRecordUpdateUnchanged
+		}
+
+		return data.DnsRecordUpdateResult{Outcome: outcome, Message: result.Message, Data: r.currentData}
+	}
+
+	if errors.Is(result.Error, common.ErrUpdateSuperseded) {
+		r.logger.Info("Update of DNS record superseded before it was sent")
+		r.currentData.UpdateSupersededCount++
+
+		return data.DnsRecordUpdateResult{
+			Outcome: data.DnsRecordUpdateSuperseded,
+			Data:    r.currentData,
+			Error:   result.Error,
+		}
+	}
+
+	// The caller gave up on the update, which is not a failure of the record
+	if ctx.Err() != nil && (errors.Is(result.Error, context.Canceled) ||
+		errors.Is(result.Error, context.DeadlineExceeded)) {
+		r.logger.Info("Update of DNS record abandoned by the caller", "error", result.Error)
+
+		return data.DnsRecordUpdateResult{Outcome: data.DnsRecordUpdateFailed, Data: r.currentData, Error: result.Error}
+	}
+
+	r.logger.Error("Error updating DNS record", "record_id", r.currentData.Id, "error", result.Error)
+	r.currentData.LastError = result.Error
+	r.currentData.LastErrorTime = time.Now()
+	r.currentData.UpdateErrorCount++
+	r.emitEvent(events.DnsRecordUpdateFailedEvent{EntityEvent: r.entityEvent(), Error: result.Error})
+
+	return data.DnsRecordUpdateResult{Outcome: data.DnsRecordUpdateFailed, Data: r.currentData, Error: result.Error}
+}
+
+// MustBeAbsent returns true if the record is configured to not exist in the zone.
+func (r *DnsRecord) MustBeAbsent() bool {
+	return r.mustBeAbsent
+}
+
+func (r *DnsRecord) Delete() error {
+	r.logger.Debug("Received request to delete DNS record", "record_id", r.currentData.Id)
+	resultCh := make(chan common.DnsRecordResult)
+	request := common.Request{
+		RequestType: common.RequestTypeDeleteDnsRecord,
+		ResultCh:    resultCh,
+		DeleteDnsRecordRequest: common.DeleteDnsRecordRequest{
+			Domain: r.domain,
+			Type:   r.currentData.Type,
+			Name:   r.currentData.Name,
+			Id:     r.currentData.Id,
+		},
+	}
+
+	err := r.requestHandlerFn(request)
+
+	if err != nil {
+		return fmt.Errorf("failed to enqueue DNS record %s delete: %w", r.currentData.Name, err)
+	}
+
+	go readAndProcessResult(r, resultCh, r.processDeleteResult, "delete DNS record")
+
+	return nil
+}
+
+// deleteSource returns the source of deletes, which are required by the configuration for records that must be
+// absent.
+func (r *DnsRecord) deleteSource() data.DnsRecordChangeSource {
+	if r.mustBeAbsent {
+		return data.DnsRecordChangeSourceConfiguration
+	}
+
+	return data.DnsRecordChangeSourceCaller
+}
+
+func (r *DnsRecord) processDeleteResult(result common.DnsRecordResult) {
+	if result.Error == nil {
+		r.logger.Info("Deleted DNS record successfully", "record_id", r.currentData.Id, "message", result.Message)
+		_, oldSpec := r.observedSpec()
+		r.currentData.Id = ""
+		r.currentData.Value = ""
+		r.currentData.Absent = true
+		r.currentData.LastUpdateTime = result.CurrentData.LastUpdateTime
+
+		// Only set when the record existed
+		if !result.CurrentData.LastModifiedTime.IsZero() {
+			r.currentData.LastModifiedTime = result.CurrentData.LastModifiedTime
+			r.appendChange(r.deleteSource(), oldSpec, nil)
+			r.emitEvent(events.DnsRecordDeletedEvent{EntityEvent: r.entityEvent()})
+		}
+
+		r.currentData.DeleteSuccessCount++
 	} else {
-		fmt.Printf("Error updating DNS record %s: %v\n", r.currentData.Name, result.Error)
+		r.logger.Error("Error deleting DNS record", "record_id", r.currentData.Id, "error", result.Error)
 		r.currentData.LastError = result.Error
 		r.currentData.LastErrorTime = time.Now()
-		r.currentData.UpdateErrorCount++
+		r.currentData.DeleteErrorCount++
+
+		if r.mustBeAbsent {
+			r.pollFailed()
+		}
 	}
 }
 
@@ -126,7 +693,7 @@ func (r *DnsRecord) processOnEntityStubAvailableListeners(container spi.IPMAASCo
 	err := container.EnqueueOnServerGoRoutine(invocations)
 
 	if err != nil {
-		fmt.Printf("error enqueuing DnsRecordEntityStubAvailableEvent listener invocations: %v\n", err)
+		r.logger.Error("Error enqueuing DnsRecordEntityStubAvailableEvent listener invocations", "error", err)
 	}
 }
 
@@ -137,7 +704,7 @@ func (r *DnsRecord) GetStub() entities.DnsRecord {
 	if r.stub == nil {
 		r.stub = NewDnsRecordStub(
 			r.id,
-			&spicommon.ThreadSafeEntityWrapper[entities.DnsRecord]{
+			&spicommon.ThreadSafeEntityWrapper[*DnsRecord]{
 				Container: r.container,
 				Entity:    r,
 			})
@@ -152,27 +719,70 @@ func (r *DnsRecord) PmaasEntityId() string {
 
 func (r *DnsRecord) CloseStubIfPresent() {
 	if r.stub != nil {
-		r.stub.Close()
+		if err := r.stub.Close(); err != nil {
+			r.logger.Error("Failed to close DnsRecordStub", "error", err)
+		}
+
 		r.stub = nil
 	}
 }
 
+// StartPolling schedules the first refresh of the record.
+func (r *DnsRecord) StartPolling(now time.Time) {
+	if r.pollSchedule != nil {
+		r.pollSchedule.Start(now)
+	}
+}
+
+// RefreshIfDue refreshes the record if its poll schedule says a refresh is due.
+func (r *DnsRecord) RefreshIfDue(now time.Time) error {
+	if r.pollSchedule == nil || !r.pollSchedule.Due(now) {
+		return nil
+	}
+
+	err := r.Refresh()
+
+	if err != nil {
+		r.pollSchedule.Failed(now)
+	}
+
+	return err
+}
+
+// pollFailed brings the next refresh forward after a failed refresh.
+func (r *DnsRecord) pollFailed() {
+	if r.pollSchedule != nil {
+		r.pollSchedule.Failed(time.Now())
+	}
+}
+
 func (r *DnsRecord) Refresh() error {
+	// Discovered records are refreshed when their domain is retrieved
+	if r.discovered {
+		return nil
+	}
+
+	// Records that must be absent are refreshed by making sure they're deleted
+	if r.mustBeAbsent {
+		return r.Delete()
+	}
+
 	resultCh := make(chan common.DnsRecordResult)
 	request := common.Request{
 		RequestType: common.RequestTypeGetDnsRecord,
 		ResultCh:    resultCh,
 		GetDnsRecordRequest: common.GetDnsRecordRequest{
-			Domain: r.domain,
-			Type:   r.currentData.Type,
-			Name:   r.currentData.Name,
+			Domain:          r.domain,
+			Type:            r.currentData.Type,
+			Name:            r.currentData.Name,
+			CreateIfMissing: r.createSpec,
 		},
 	}
 
 	err := r.requestHandlerFn(request)
 
 	if err != nil {
-		return fmt.Errorf("failed to enqueue DNS record %s retrieval: %v", r.currentData.Name, err)
+		return fmt.Errorf("failed to enqueue DNS record %s retrieval: %w", r.currentData.Name, err)
 	}
 
 	go readAndProcessResult(r, resultCh, r.processGetDnsRecordResult, "DNS record retrieval")
@@ -182,18 +792,166 @@ func (r *DnsRecord) Refresh() error {
 
 func (r *DnsRecord) processGetDnsRecordResult(result common.DnsRecordResult) {
 	if result.Error == nil {
-		fmt.Printf("%T DNS record %s: %s\n", r, result.CurrentData.Name, result.Message)
+		r.logger.Debug("Retrieved DNS record",
+			"record_id", result.CurrentData.Id, "value", result.CurrentData.Value, "message", result.Message)
+		oldValue := r.currentData.Value
+		observed, oldSpec := r.observedSpec()
 		r.updateData(&result.CurrentData)
+
+		if result.Created {
+			r.recordChange(data.DnsRecordChangeSourceConfiguration, oldSpec)
+		} else if observed {
+			r.recordChange(data.DnsRecordChangeSourceExternal, oldSpec)
+		}
+
+		// Set when the record was created because it was missing
+		if !result.CurrentData.LastModifiedTime.IsZero() {
+			r.currentData.LastModifiedTime = result.CurrentData.LastModifiedTime
+			written := specOf(&r.currentData)
+			r.lastWritten = &written
+		}
+
 		r.currentData.GetSuccessCount++
+		r.emitChangeEvents(&result, oldValue)
+		r.detectDrift()
+		r.reconcileIfNeeded()
 	} else {
-		fmt.Printf("Error retrieving DNS record %s: %v\n", r.currentData.Name, result.Error)
+		r.logger.Error("Error retrieving DNS record", "error", result.Error)
 		r.currentData.LastError = result.Error
 		r.currentData.LastErrorTime = time.Now()
 		r.currentData.GetErrorCount++
+		r.pollFailed()
+		r.emitEvent(events.DnsRecordRetrievalFailedEvent{EntityEvent: r.entityEvent(), Error: result.Error})
+	}
+}
+
+// detectDrift compares the current state of a record watched for drift with the state last written by the plugin.  A
+// difference is recorded and broadcast, once for each distinct state found.
+func (r *DnsRecord) detectDrift() {
+	if !r.watchDrift || r.lastWritten == nil {
+		return
 	}
+
+	actual := specOf(&r.currentData)
+
+	if actual == *r.lastWritten {
+		return
+	}
+
+	if r.currentData.LastDrift != nil && r.currentData.LastDrift.Actual == actual {
+		return
+	}
+
+	drift := data.DnsRecordDrift{
+		DetectionTime: time.Now(),
+		Expected:      *r.lastWritten,
+		Actual:        actual,
+	}
+	r.logger.Warn("DNS record was changed outside the plugin", "expected", drift.Expected, "actual", drift.Actual)
+	r.currentData.DriftCount++
+	r.currentData.LastDrift = &drift
+
+	r.emitEvent(events.DnsRecordDriftDetectedEvent{EntityEvent: r.entityEvent(), Drift: drift})
+}
+
+func (r *DnsRecord) entityEvent() spievents.EntityEvent {
+	return spievents.EntityEvent{
+		Id:         r.pmaasEntityId,
+		EntityType: entities.DnsRecordType,
+		Name:       r.currentData.Name,
+	}
+}
+
+// emitEvent broadcasts the event to the PMAAS event system, and passes it to the listeners registered via
+// configuration, on the server goroutine.
+func (r *DnsRecord) emitEvent(event any) {
+	if r.pmaasEntityId == "" {
+		return
+	}
+
+	err := r.container.BroadcastEvent(r.pmaasEntityId, event)
+
+	if err != nil {
+		r.logger.Error("Error broadcasting event", "event_type", fmt.Sprintf("%T", event), "error", err)
+	}
+
+	numListeners := len(r.eventListeners)
+
+	if numListeners == 0 {
+		return
+	}
+
+	invocations := make([]func(), numListeners)
+
+	for i, listener := range r.eventListeners {
+		invocations[i] = func() { listener(event) }
+	}
+
+	err = r.container.EnqueueOnServerGoRoutine(invocations)
+
+	if err != nil {
+		r.logger.Error("Error enqueuing listener invocations", "event_type", fmt.Sprintf("%T", event), "error", err)
+	}
+}
+
+// emitChangeEvents emits the created and value changed events for a successful result, based on the previous value.
+func (r *DnsRecord) emitChangeEvents(result *common.DnsRecordResult, oldValue string) {
+	if result.Created {
+		r.emitEvent(events.DnsRecordCreatedEvent{EntityEvent: r.entityEvent(), Data: r.currentData})
+	}
+
+	// An unknown old value means the record was never retrieved, rather than changed
+	if (oldValue != "" || result.Created) && oldValue != r.currentData.Value {
+		r.emitEvent(events.DnsRecordValueChangedEvent{
+			EntityEvent: r.entityEvent(),
+			OldValue:    oldValue,
+			NewValue:    r.currentData.Value,
+			Data:        r.currentData,
+		})
+	}
+}
+
+func specOf(recordData *data.DnsRecordData) data.DnsRecordSpec {
+	return data.DnsRecordSpec{
+		Value:    recordData.Value,
+		Ttl:      recordData.Ttl,
+		Priority: recordData.Priority,
+		Notes:    recordData.Notes,
+	}
+}
+
+// reconcileIfNeeded updates the record if it's configured to reconcile, and its current state differs from the
+// configured state.
+func (r *DnsRecord) reconcileIfNeeded() {
+	if !r.reconcile || r.currentData.Absent {
+		return
+	}
+
+	desired := r.createSpec
+
+	if r.currentData.Value == desired.Value &&
+		(desired.Ttl == 0 || r.currentData.Ttl == desired.Ttl) &&
+		r.currentData.Priority == desired.Priority &&
+		r.currentData.Notes == desired.Notes {
+		return
+	}
+
+	r.logger.Info("DNS record differs from its configured state, reconciling",
+		"current", specOf(&r.currentData), "desired", *desired)
+	err := r.enqueueUpdate(context.Background(), *desired, false, data.DnsRecordChangeSourceReconciliation, nil)
+
+	if err != nil {
+		r.logger.Error("Error reconciling DNS record", "error", err)
+		return
+	}
+
+	r.currentData.ReconcileCount++
+	r.currentData.LastReconcileTime = time.Now()
 }
 
 func (r *DnsRecord) updateData(data *data.DnsRecordData) {
+	r.currentData.Id = data.Id
+	r.currentData.Absent = data.Absent
 	r.currentData.LastUpdateTime = data.LastUpdateTime
 	r.currentData.Value = data.Value
 	r.currentData.Ttl = data.Ttl
@@ -205,9 +963,12 @@ func (r *DnsRecord) updateData(data *data.DnsRecordData) {
 
 func readAndProcessResult[T any](r *DnsRecord, resultCh <-chan T, processFn func(T), resultDescription string) {
 	result := <-resultCh
-	err := r.container.EnqueueOnPluginGoRoutine(func() { processFn(result) })
+	err := r.container.EnqueueOnPluginGoRoutine(func() {
+		processFn(result)
+		r.changed()
+	})
 
 	if err != nil {
-		fmt.Printf("%T Error processing %s result: %v\n", r, resultDescription, err)
+		r.logger.Error("Error processing result", "result", resultDescription, "error", err)
 	}
 }
@@ -1,21 +1,21 @@
 package dnsRecord
 
 import (
+	"context"
 	"fmt"
 	"sync/atomic"
 
 	"github.com/avanha/pmaas-plugin-porkbun/data"
-	"github.com/avanha/pmaas-plugin-porkbun/entities"
 	spicommon "github.com/avanha/pmaas-spi/common"
 )
 
 type DnsRecordStub struct {
 	pmaasEntityId          string
 	closeFn                func() error
-	entityWrapperReference atomic.Pointer[spicommon.ThreadSafeEntityWrapper[entities.DnsRecord]]
+	entityWrapperReference atomic.Pointer[spicommon.ThreadSafeEntityWrapper[*DnsRecord]]
 }
 
-func NewDnsRecordStub(pmaasEntityId string, entityWrapper *spicommon.ThreadSafeEntityWrapper[entities.DnsRecord]) *DnsRecordStub {
+func NewDnsRecordStub(pmaasEntityId string, entityWrapper *spicommon.ThreadSafeEntityWrapper[*DnsRecord]) *DnsRecordStub {
 	stub := &DnsRecordStub{
 		pmaasEntityId: pmaasEntityId,
 	}
@@ -37,31 +37,72 @@ func NewDnsRecordStub(pmaasEntityId string, entityWrapper *spicommon.ThreadSafeE
 func (s *DnsRecordStub) Data() data.DnsRecordData {
 	return spicommon.ThreadSafeEntityWrapperExecValueFunc(
 		s.entityWrapperReference.Load(),
-		func(target entities.DnsRecord) data.DnsRecordData { return target.Data() })
+		func(target *DnsRecord) data.DnsRecordData { return target.Data() })
+}
+
+func (s *DnsRecordStub) History() []data.DnsRecordChange {
+	return spicommon.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target *DnsRecord) []data.DnsRecordChange { return target.History() })
 }
 
 func (s *DnsRecordStub) Name() string {
 	return spicommon.ThreadSafeEntityWrapperExecValueFunc(
 		s.entityWrapperReference.Load(),
-		func(target entities.DnsRecord) string { return target.Name() })
+		func(target *DnsRecord) string { return target.Name() })
 }
 
 func (s *DnsRecordStub) UpdateValue(value string) error {
 	return spicommon.ThreadSafeEntityWrapperExecValueFunc(
 		s.entityWrapperReference.Load(),
-		func(target entities.DnsRecord) error { return target.UpdateValue(value) })
+		func(target *DnsRecord) error { return target.UpdateValue(value) })
 }
 
-func (s *DnsRecordStub) Close() {
-	closeFn := s.closeFn
+func (s *DnsRecordStub) UpdateValueAndWait(ctx context.Context, value string) (data.DnsRecordUpdateResult, error) {
+	type asyncUpdate struct {
+		resultCh <-chan data.DnsRecordUpdateResult
+		err      error
+	}
 
-	if closeFn == nil {
-		return
+	// Only start the update on the plugin goroutine; waiting there would block the processing of the result
+	update := spicommon.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target *DnsRecord) asyncUpdate {
+			resultCh, err := target.UpdateValueAsync(ctx, value)
+			return asyncUpdate{resultCh: resultCh, err: err}
+		})
+
+	if update.err != nil {
+		return data.DnsRecordUpdateResult{Outcome: data.DnsRecordUpdateFailed, Error: update.err}, update.err
 	}
 
-	err := closeFn()
+	select {
+	case result := <-update.resultCh:
+		return result, result.Error
+	case <-ctx.Done():
+		return data.DnsRecordUpdateResult{Outcome: data.DnsRecordUpdateFailed, Error: ctx.Err()}, ctx.Err()
+	}
+}
 
-	if err != nil {
-		fmt.Printf("Failed to close DnsRecordStub %s: %v", s.Name(), err)
+func (s *DnsRecordStub) Update(spec data.DnsRecordSpec) error {
+	return spicommon.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target *DnsRecord) error { return target.Update(spec) })
+}
+
+func (s *DnsRecordStub) Delete() error {
+	return spicommon.ThreadSafeEntityWrapperExecValueFunc(
+		s.entityWrapperReference.Load(),
+		func(target *DnsRecord) error { return target.Delete() })
+}
+
+// Close detaches the stub from the entity, so the entity can be garbage collected.
+func (s *DnsRecordStub) Close() error {
+	closeFn := s.closeFn
+
+	if closeFn == nil {
+		return nil
 	}
+
+	return closeFn()
 }
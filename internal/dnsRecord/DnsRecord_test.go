@@ -0,0 +1,185 @@
+package dnsRecord
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/data"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+	"github.com/avanha/pmaas-spi"
+)
+
+// fakeContainer queues the functions enqueued on the plugin goroutine, so the test can run them, in place of the
+// plugin goroutine.
+type fakeContainer struct {
+	spi.IPMAASContainer
+	pluginCalls chan func()
+	enqueueErr  error
+}
+
+func (c *fakeContainer) EnqueueOnPluginGoRoutine(f func()) error {
+	if c.enqueueErr != nil {
+		return c.enqueueErr
+	}
+
+	c.pluginCalls <- f
+
+	return nil
+}
+
+// newTestDnsRecord returns a record, its container, and a channel receiving the requests the record sends.
+func newTestDnsRecord(t *testing.T) (*DnsRecord, *fakeContainer, chan common.Request) {
+	t.Helper()
+	container := &fakeContainer{pluginCalls: make(chan func(), 10)}
+	requestCh := make(chan common.Request, 10)
+	record := NewDnsRecord(
+		container,
+		"DnsRecord_1",
+		"example.com",
+		"A",
+		"www",
+		Options{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))},
+		func(request common.Request) error {
+			requestCh <- request
+			return nil
+		},
+		nil)
+
+	return record, container, requestCh
+}
+
+// updateValue starts an update of the record, failing the test if it can't be enqueued.
+func updateValue(t *testing.T, record *DnsRecord, value string) <-chan data.DnsRecordUpdateResult {
+	t.Helper()
+	waiterCh, err := record.UpdateValueAsync(context.Background(), value)
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return waiterCh
+}
+
+// respond completes the request with the result, and runs the processing of the result on the calling goroutine.
+func respond(container *fakeContainer, request common.Request, result common.DnsRecordResult) {
+	request.ResultCh <- result
+	(<-container.pluginCalls)()
+}
+
+func succeeded(request common.Request) common.DnsRecordResult {
+	return common.DnsRecordResult{
+		CurrentData: data.DnsRecordData{
+			Id:             "1",
+			Name:           "www",
+			Type:           "A",
+			Value:          request.UpdateDnsRecordRequest.Desired.Value,
+			LastUpdateTime: time.Now(),
+		},
+	}
+}
+
+func TestUpdatesAreCoalesced(t *testing.T) {
+	record, container, requestCh := newTestDnsRecord(t)
+	firstCh := updateValue(t, record, "192.0.2.1")
+	first := <-requestCh
+	secondCh := updateValue(t, record, "192.0.2.2")
+	thirdCh := updateValue(t, record, "192.0.2.3")
+
+	if len(requestCh) != 0 {
+		t.Fatal("got a request while another update is in flight, want the update kept pending")
+	}
+
+	// The pending update is superseded by the later one, and the update in flight is abandoned
+	if result := <-secondCh; result.Outcome != data.DnsRecordUpdateSuperseded {
+		t.Errorf("got second outcome %v, want superseded", result.Outcome)
+	}
+
+	if cause := context.Cause(first.Context); !errors.Is(cause, common.ErrUpdateSuperseded) {
+		t.Errorf("got cause %v for the update in flight, want %v", cause, common.ErrUpdateSuperseded)
+	}
+
+	respond(container, first, common.DnsRecordResult{Error: context.Cause(first.Context)})
+
+	if result := <-firstCh; result.Outcome != data.DnsRecordUpdateSuperseded {
+		t.Errorf("got first outcome %v, want superseded", result.Outcome)
+	}
+
+	third := <-requestCh
+
+	if third.UpdateDnsRecordRequest.Desired.Value != "192.0.2.3" {
+		t.Errorf("got value %q sent, want the latest value", third.UpdateDnsRecordRequest.Desired.Value)
+	}
+
+	respond(container, third, succeeded(third))
+
+	if result := <-thirdCh; result.Outcome != data.DnsRecordUpdateUpdated || result.Data.Value != "192.0.2.3" {
+		t.Errorf("got %+v, want the latest value updated", result)
+	}
+
+	if count := record.Data().UpdateSupersededCount; count != 2 {
+		t.Errorf("got %d superseded updates, want 2", count)
+	}
+}
+
+func TestAbandonedPendingUpdateIsNotSent(t *testing.T) {
+	record, container, requestCh := newTestDnsRecord(t)
+	updateValue(t, record, "192.0.2.1")
+	first := <-requestCh
+	ctx, cancel := context.WithCancel(context.Background())
+	pendingCh, err := record.UpdateValueAsync(ctx, "192.0.2.2")
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	cancel()
+	respond(container, first, common.DnsRecordResult{Error: context.Cause(first.Context)})
+
+	result := <-pendingCh
+
+	if result.Outcome != data.DnsRecordUpdateFailed || !errors.Is(result.Error, context.Canceled) {
+		t.Errorf("got %+v, want the pending update abandoned", result)
+	}
+
+	if len(requestCh) != 0 {
+		t.Error("got a request for the abandoned update, want none")
+	}
+
+	if count := record.Data().UpdateErrorCount; count != 0 {
+		t.Errorf("got %d update errors, want none for abandoned updates", count)
+	}
+}
+
+func TestUpdateResultThatCantBeProcessedReleasesTheRecord(t *testing.T) {
+	record, container, requestCh := newTestDnsRecord(t)
+	firstCh := updateValue(t, record, "192.0.2.1")
+	first := <-requestCh
+	pendingCh := updateValue(t, record, "192.0.2.2")
+	container.enqueueErr = errors.New("plugin is not running")
+	first.ResultCh <- succeeded(first)
+
+	if result := <-firstCh; result.Outcome != data.DnsRecordUpdateFailed {
+		t.Errorf("got first outcome %v, want failed", result.Outcome)
+	}
+
+	if result := <-pendingCh; result.Outcome != data.DnsRecordUpdateFailed {
+		t.Errorf("got pending outcome %v, want failed", result.Outcome)
+	}
+
+	// Later updates are sent, rather than waiting for the update that was in flight
+	container.enqueueErr = nil
+	updateValue(t, record, "192.0.2.3")
+
+	select {
+	case request := <-requestCh:
+		if request.UpdateDnsRecordRequest.Desired.Value != "192.0.2.3" {
+			t.Errorf("got value %q sent, want the new value", request.UpdateDnsRecordRequest.Desired.Value)
+		}
+	case <-time.After(time.Second):
+		t.Error("got no request, want the update sent")
+	}
+}
@@ -0,0 +1,174 @@
+package http
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+)
+
+// updateWaitTimeout is how long the edit action waits for the result of an update before reporting it as pending.
+const updateWaitTimeout = 10 * time.Second
+
+// maxOutcomes is the number of action outcomes kept for display after the redirect back to the status page.
+const maxOutcomes = 20
+
+// outcomes holds the messages describing the outcome of actions.  The redirect after an action refers to its message
+// by id, so messages are never echoed from the URL.
+type outcomes struct {
+	mutex    sync.Mutex
+	messages map[int]string
+	nextId   int
+}
+
+func (o *outcomes) add(message string) int {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	if o.messages == nil {
+		o.messages = make(map[int]string)
+	}
+
+	o.nextId++
+	o.messages[o.nextId] = message
+	delete(o.messages, o.nextId-maxOutcomes)
+
+	return o.nextId
+}
+
+func (o *outcomes) get(id int) string {
+	o.mutex.Lock()
+	defer o.mutex.Unlock()
+
+	return o.messages[id]
+}
+
+func newCsrfToken() string {
+	tokenBytes := make([]byte, 32)
+	_, _ = rand.Read(tokenBytes)
+
+	return hex.EncodeToString(tokenBytes)
+}
+
+// checkActionRequest verifies that the request is a POST carrying the CSRF token of the forms on the status page,
+// writing an error response if it isn't.
+func (h *Handler) checkActionRequest(writer http.ResponseWriter, request *http.Request) bool {
+	if request.Method != http.MethodPost {
+		writer.Header().Set("Allow", http.MethodPost)
+		http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+
+	if request.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		http.Error(writer, "Cross-site requests are not allowed", http.StatusForbidden)
+		return false
+	}
+
+	token := request.PostFormValue("csrf_token")
+
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.csrfToken)) != 1 {
+		http.Error(writer, "Invalid or missing CSRF token", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// redirectWithOutcome sends the browser back to the status page, showing the passed message.
+func (h *Handler) redirectWithOutcome(writer http.ResponseWriter, request *http.Request, message string) {
+	target := "/plugins/porkbun/?outcome=" + strconv.Itoa(h.outcomes.add(message))
+	http.Redirect(writer, request, target, http.StatusSeeOther)
+}
+
+// outcomeMessage returns the message of the action outcome referenced by the request, if any.
+func (h *Handler) outcomeMessage(request *http.Request) string {
+	id, err := strconv.Atoi(request.URL.Query().Get("outcome"))
+
+	if err != nil {
+		return ""
+	}
+
+	return h.outcomes.get(id)
+}
+
+func (h *Handler) handleHttpRefreshRequest(writer http.ResponseWriter, request *http.Request) {
+	if !h.checkActionRequest(writer, request) {
+		return
+	}
+
+	domain := request.PostFormValue("domain")
+	recordType := request.PostFormValue("type")
+	name := request.PostFormValue("name")
+	var err error
+	var message string
+
+	if domain == "" {
+		err = h.entityStore.RefreshAll()
+		message = "Refresh of all DNS records requested"
+	} else {
+		err = h.entityStore.RefreshDnsRecord(domain, recordType, name)
+		message = fmt.Sprintf("Refresh of DNS record %s %s %s requested", domain, recordType, name)
+	}
+
+	if err != nil {
+		h.logger.Error("Error refreshing", "domain", domain, "type", recordType, "name", name, "error", err)
+		message = fmt.Sprintf("Refresh failed: %s", err)
+	}
+
+	h.redirectWithOutcome(writer, request, message)
+}
+
+func (h *Handler) handleHttpEditRequest(writer http.ResponseWriter, request *http.Request) {
+	if !h.checkActionRequest(writer, request) {
+		return
+	}
+
+	domain := request.PostFormValue("domain")
+	recordType := request.PostFormValue("type")
+	name := request.PostFormValue("name")
+	value := strings.TrimSpace(request.PostFormValue("value"))
+	patch := common.DnsRecordPatch{Value: &value}
+
+	if ttlString := strings.TrimSpace(request.PostFormValue("ttl")); ttlString != "" {
+		ttl, err := strconv.ParseInt(ttlString, 10, 32)
+
+		if err != nil {
+			h.redirectWithOutcome(writer, request, fmt.Sprintf("Invalid TTL \"%s\"", ttlString))
+			return
+		}
+
+		ttl32 := int32(ttl)
+		patch.Ttl = &ttl32
+	}
+
+	ctx, cancel := context.WithTimeout(request.Context(), updateWaitTimeout)
+	defer cancel()
+	result, err := h.entityStore.UpdateDnsRecord(ctx, domain, recordType, name, patch)
+	var message string
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		message = fmt.Sprintf("Update of DNS record %s %s %s is still pending, check back later",
+			domain, recordType, name)
+	case errors.Is(err, common.ErrUpdateSuperseded):
+		message = fmt.Sprintf("Update of DNS record %s %s %s was superseded by a later update",
+			domain, recordType, name)
+	case err != nil:
+		h.logger.Error("Error updating DNS record", "domain", domain, "type", recordType, "name", name,
+			"error", err)
+		message = fmt.Sprintf("Update of DNS record %s %s %s failed: %s", domain, recordType, name, err)
+	default:
+		message = fmt.Sprintf("DNS record %s %s %s %s", domain, recordType, name, result.Outcome)
+	}
+
+	h.redirectWithOutcome(writer, request, message)
+}
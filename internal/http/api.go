@@ -0,0 +1,301 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+)
+
+const apiPathPrefix = "/plugins/porkbun/api/"
+
+// defaultApiUpdateWait is how long an API update waits for its result when the request doesn't specify the wait
+// parameter.
+const defaultApiUpdateWait = 30 * time.Second
+
+// maxApiRequestBodySize limits the size of the JSON bodies accepted by the API.
+const maxApiRequestBodySize = 64 * 1024
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+// apiRecordPath identifies a record and, optionally, an operation on it, e.g. records/example.com/A/www/history.  The
+// name of the apex record is empty, e.g. records/example.com/A/ and records/example.com/A//history.
+type apiRecordPath struct {
+	domain     string
+	recordType string
+	name       string
+	operation  string
+}
+
+// logArgs returns the attributes identifying the record in log entries, followed by args.
+func (p apiRecordPath) logArgs(args ...any) []any {
+	return append([]any{"domain", p.domain, "type", p.recordType, "name", p.name}, args...)
+}
+
+// handleHttpApiRequest dispatches the requests under /plugins/porkbun/api/:
+//
+//	GET    status                                  plugin status
+//	GET    records                                 all records
+//	POST   refresh                                 refresh all records
+//	GET    records/{domain}/{type}/{name}          a record
+//	PUT    records/{domain}/{type}/{name}          update a record with a common.DnsRecordPatch body
+//	DELETE records/{domain}/{type}/{name}          delete a record
+//	GET    records/{domain}/{type}/{name}/history  a record and its change history
+//	POST   records/{domain}/{type}/{name}/refresh  refresh a record
+func (h *Handler) handleHttpApiRequest(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Header.Get("Sec-Fetch-Site") == "cross-site" {
+		h.writeApiError(writer, http.StatusForbidden, errors.New("cross-site requests are not allowed"))
+		return
+	}
+
+	path := strings.TrimPrefix(request.URL.Path, apiPathPrefix)
+
+	switch {
+	case path == "status":
+		h.handleApiStatusRequest(writer, request)
+	case path == "records":
+		h.handleApiRecordsRequest(writer, request)
+	case path == "refresh":
+		h.handleApiRefreshAllRequest(writer, request)
+	case strings.HasPrefix(path, "records/"):
+		recordPath, ok := parseApiRecordPath(strings.TrimPrefix(path, "records/"))
+
+		if !ok {
+			h.writeApiError(writer, http.StatusNotFound, fmt.Errorf("unknown API path \"%s\"", path))
+			return
+		}
+
+		h.handleApiRecordRequest(writer, request, recordPath)
+	default:
+		h.writeApiError(writer, http.StatusNotFound, fmt.Errorf("unknown API path \"%s\"", path))
+	}
+}
+
+func parseApiRecordPath(path string) (apiRecordPath, bool) {
+	segments := strings.Split(path, "/")
+
+	if len(segments) < 3 || len(segments) > 4 || segments[0] == "" || segments[1] == "" {
+		return apiRecordPath{}, false
+	}
+
+	recordPath := apiRecordPath{domain: segments[0], recordType: segments[1], name: segments[2]}
+
+	if len(segments) == 4 {
+		recordPath.operation = segments[3]
+	}
+
+	return recordPath, true
+}
+
+func (h *Handler) handleApiStatusRequest(writer http.ResponseWriter, request *http.Request) {
+	if !h.checkApiMethod(writer, request, http.MethodGet) {
+		return
+	}
+
+	result, err := h.entityStore.GetStatusAndEntities()
+
+	if err != nil {
+		h.writeApiEntityStoreError(writer, "handleApiStatusRequest", err)
+		return
+	}
+
+	h.writeApiResponse(writer, http.StatusOK, result.Status)
+}
+
+func (h *Handler) handleApiRecordsRequest(writer http.ResponseWriter, request *http.Request) {
+	if !h.checkApiMethod(writer, request, http.MethodGet) {
+		return
+	}
+
+	result, err := h.entityStore.GetStatusAndEntities()
+
+	if err != nil {
+		h.writeApiEntityStoreError(writer, "handleApiRecordsRequest", err)
+		return
+	}
+
+	h.writeApiResponse(writer, http.StatusOK, result.DnsRecords)
+}
+
+func (h *Handler) handleApiRefreshAllRequest(writer http.ResponseWriter, request *http.Request) {
+	if !h.checkApiMethod(writer, request, http.MethodPost) {
+		return
+	}
+
+	if err := h.entityStore.RefreshAll(); err != nil {
+		h.writeApiEntityStoreError(writer, "handleApiRefreshAllRequest", err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) handleApiRecordRequest(writer http.ResponseWriter, request *http.Request, path apiRecordPath) {
+	switch path.operation {
+	case "":
+		switch request.Method {
+		case http.MethodGet:
+			h.handleApiGetRecordRequest(writer, path, false)
+		case http.MethodPut, http.MethodPatch:
+			h.handleApiUpdateRecordRequest(writer, request, path)
+		case http.MethodDelete:
+			h.handleApiDeleteRecordRequest(writer, path)
+		default:
+			writer.Header().Set("Allow", "GET, PUT, PATCH, DELETE")
+			h.writeApiError(writer, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", request.Method))
+		}
+	case "history":
+		if h.checkApiMethod(writer, request, http.MethodGet) {
+			h.handleApiGetRecordRequest(writer, path, true)
+		}
+	case "refresh":
+		if h.checkApiMethod(writer, request, http.MethodPost) {
+			h.handleApiRefreshRecordRequest(writer, path)
+		}
+	default:
+		h.writeApiError(writer, http.StatusNotFound, fmt.Errorf("unknown record operation \"%s\"", path.operation))
+	}
+}
+
+func (h *Handler) handleApiGetRecordRequest(writer http.ResponseWriter, path apiRecordPath, withHistory bool) {
+	result, err := h.entityStore.GetDnsRecordHistory(path.domain, path.recordType, path.name)
+
+	if err != nil {
+		h.writeApiEntityStoreError(writer, "handleApiGetRecordRequest", err)
+		return
+	}
+
+	if withHistory {
+		h.writeApiResponse(writer, http.StatusOK, result)
+	} else {
+		h.writeApiResponse(writer, http.StatusOK, result.Data)
+	}
+}
+
+// handleApiUpdateRecordRequest applies the patch in the body to the record.  The wait query parameter is a duration,
+// like 10s, to wait for the result; with a wait of zero, or when the wait expires, the response is 202 Accepted and
+// the update continues.
+func (h *Handler) handleApiUpdateRecordRequest(writer http.ResponseWriter, request *http.Request, path apiRecordPath) {
+	wait := defaultApiUpdateWait
+
+	if waitString := request.URL.Query().Get("wait"); waitString != "" {
+		var err error
+		wait, err = time.ParseDuration(waitString)
+
+		if err != nil || wait < 0 {
+			h.writeApiError(writer, http.StatusBadRequest, fmt.Errorf("invalid wait \"%s\"", waitString))
+			return
+		}
+	}
+
+	var patch common.DnsRecordPatch
+	decoder := json.NewDecoder(http.MaxBytesReader(writer, request.Body, maxApiRequestBodySize))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(&patch); err != nil {
+		h.writeApiError(writer, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	// Check that the plugin manages the record first, so that a record that is missing from the domain is reported as
+	// a failed update rather than as an unknown record
+	if _, err := h.entityStore.GetDnsRecordHistory(path.domain, path.recordType, path.name); err != nil {
+		h.writeApiEntityStoreError(writer, "handleApiUpdateRecordRequest", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(request.Context(), wait)
+	defer cancel()
+	result, err := h.entityStore.UpdateDnsRecord(ctx, path.domain, path.recordType, path.name, patch)
+
+	switch {
+	case err == nil:
+		h.writeApiResponse(writer, http.StatusOK, result)
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled):
+		// The update continues
+		writer.WriteHeader(http.StatusAccepted)
+	case errors.Is(err, common.ErrUpdateSuperseded):
+		h.writeApiResponse(writer, http.StatusConflict, result)
+	case errors.Is(err, common.ErrInvalidDnsRecordUpdate) || errors.Is(err, common.ErrDnsRecordReadOnly):
+		h.writeApiEntityStoreError(writer, "handleApiUpdateRecordRequest", err)
+	default:
+		// The update failed while calling the API, or the API reported an error
+		h.logger.Error("Error updating DNS record", path.logArgs("error", err)...)
+		h.writeApiResponse(writer, http.StatusBadGateway, result)
+	}
+}
+
+func (h *Handler) handleApiDeleteRecordRequest(writer http.ResponseWriter, path apiRecordPath) {
+	if err := h.entityStore.DeleteDnsRecord(path.domain, path.recordType, path.name); err != nil {
+		h.writeApiEntityStoreError(writer, "handleApiDeleteRecordRequest", err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
+}
+
+func (h *Handler) handleApiRefreshRecordRequest(writer http.ResponseWriter, path apiRecordPath) {
+	if err := h.entityStore.RefreshDnsRecord(path.domain, path.recordType, path.name); err != nil {
+		h.writeApiEntityStoreError(writer, "handleApiRefreshRecordRequest", err)
+		return
+	}
+
+	writer.WriteHeader(http.StatusAccepted)
+}
+
+// checkApiMethod verifies the method of the request, writing an error response if it doesn't match.
+func (h *Handler) checkApiMethod(writer http.ResponseWriter, request *http.Request, method string) bool {
+	if request.Method == method {
+		return true
+	}
+
+	writer.Header().Set("Allow", method)
+	h.writeApiError(writer, http.StatusMethodNotAllowed, fmt.Errorf("method %s not allowed", request.Method))
+
+	return false
+}
+
+// writeApiEntityStoreError writes the response for an error returned by the entity store.
+func (h *Handler) writeApiEntityStoreError(writer http.ResponseWriter, handlerName string, err error) {
+	switch {
+	case errors.Is(err, common.ErrDnsRecordNotFound):
+		h.writeApiError(writer, http.StatusNotFound, err)
+		return
+	case errors.Is(err, common.ErrInvalidDnsRecordUpdate):
+		h.writeApiError(writer, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, common.ErrDnsRecordReadOnly):
+		h.writeApiError(writer, http.StatusConflict, err)
+		return
+	}
+
+	h.logger.Error("API request failed", "handler", handlerName, "error", err)
+	h.writeApiError(writer, http.StatusInternalServerError, err)
+}
+
+func (h *Handler) writeApiError(writer http.ResponseWriter, statusCode int, err error) {
+	h.writeApiResponse(writer, statusCode, apiError{Error: err.Error()})
+}
+
+func (h *Handler) writeApiResponse(writer http.ResponseWriter, statusCode int, value any) {
+	body, err := json.Marshal(value)
+
+	if err != nil {
+		h.logger.Error("Error marshalling API response", "error", err)
+		statusCode = http.StatusInternalServerError
+		body = []byte(`{"error":"unable to marshal the response"}`)
+	}
+
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	writer.WriteHeader(statusCode)
+	_, _ = writer.Write(body)
+}
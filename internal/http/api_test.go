@@ -0,0 +1,163 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/avanha/pmaas-plugin-porkbun/data"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+)
+
+// fakeEntityStore returns err from every call, and the record from the successful ones.
+type fakeEntityStore struct {
+	common.EntityStore
+	err       error
+	updateErr error
+}
+
+func (s *fakeEntityStore) GetDnsRecordHistory(domain string, recordType string, name string) (
+	common.DnsRecordHistory, error) {
+	return common.DnsRecordHistory{Data: data.DnsRecordData{Domain: domain, Type: recordType, Name: name}}, s.err
+}
+
+func (s *fakeEntityStore) UpdateDnsRecord(
+	ctx context.Context,
+	domain string,
+	recordType string,
+	name string,
+	patch common.DnsRecordPatch) (data.DnsRecordUpdateResult, error) {
+	return data.DnsRecordUpdateResult{}, s.updateErr
+}
+
+func (s *fakeEntityStore) DeleteDnsRecord(domain string, recordType string, name string) error {
+	return s.err
+}
+
+func (s *fakeEntityStore) RefreshDnsRecord(domain string, recordType string, name string) error {
+	return s.err
+}
+
+func newTestHandler(entityStore common.EntityStore) *Handler {
+	return &Handler{entityStore: entityStore, logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+}
+
+func serveApiRequest(handler *Handler, method string, path string, body string) *httptest.ResponseRecorder {
+	recorder := httptest.NewRecorder()
+	handler.handleHttpApiRequest(recorder, httptest.NewRequest(method, apiPathPrefix+path, strings.NewReader(body)))
+
+	return recorder
+}
+
+func TestApiEntityStoreErrorStatusCodes(t *testing.T) {
+	tests := []struct {
+		err        error
+		statusCode int
+	}{
+		{err: nil, statusCode: http.StatusOK},
+		{err: fmt.Errorf("lookup: %w", common.ErrDnsRecordNotFound), statusCode: http.StatusNotFound},
+		{err: fmt.Errorf("patch: %w", common.ErrInvalidDnsRecordUpdate), statusCode: http.StatusBadRequest},
+		{err: fmt.Errorf("delete: %w", common.ErrDnsRecordReadOnly), statusCode: http.StatusConflict},
+		{err: errors.New("plugin is not running"), statusCode: http.StatusInternalServerError},
+	}
+
+	for _, test := range tests {
+		handler := newTestHandler(&fakeEntityStore{err: test.err})
+		recorder := serveApiRequest(handler, http.MethodGet, "records/example.com/A/www", "")
+
+		if recorder.Code != test.statusCode {
+			t.Errorf("%v: got HTTP %d, want %d", test.err, recorder.Code, test.statusCode)
+		}
+
+		if test.err == nil {
+			continue
+		}
+
+		response := apiError{}
+
+		if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil || response.Error != test.err.Error() {
+			t.Errorf("%v: got body %+v (%v), want the error", test.err, response, err)
+		}
+	}
+}
+
+func TestApiUpdateStatusCodes(t *testing.T) {
+	tests := []struct {
+		err        error
+		statusCode int
+	}{
+		{err: nil, statusCode: http.StatusOK},
+		{err: context.DeadlineExceeded, statusCode: http.StatusAccepted},
+		{err: fmt.Errorf("update: %w", common.ErrUpdateSuperseded), statusCode: http.StatusConflict},
+		{err: fmt.Errorf("patch: %w", common.ErrInvalidDnsRecordUpdate), statusCode: http.StatusBadRequest},
+		{err: fmt.Errorf("update: %w", common.ErrDnsRecordReadOnly), statusCode: http.StatusConflict},
+		{err: errors.New("connection refused"), statusCode: http.StatusBadGateway},
+	}
+
+	for _, test := range tests {
+		handler := newTestHandler(&fakeEntityStore{updateErr: test.err})
+		recorder := serveApiRequest(handler, http.MethodPut, "records/example.com/A/www", `{"Value":"192.0.2.1"}`)
+
+		if recorder.Code != test.statusCode {
+			t.Errorf("%v: got HTTP %d, want %d", test.err, recorder.Code, test.statusCode)
+		}
+	}
+}
+
+func TestApiUpdateOfUnknownRecord(t *testing.T) {
+	handler := newTestHandler(&fakeEntityStore{err: common.ErrDnsRecordNotFound})
+	recorder := serveApiRequest(handler, http.MethodPut, "records/example.com/A/www", `{"Value":"192.0.2.1"}`)
+
+	if recorder.Code != http.StatusNotFound {
+		t.Errorf("got HTTP %d, want 404", recorder.Code)
+	}
+}
+
+func TestApiRequestErrors(t *testing.T) {
+	tests := []struct {
+		method     string
+		path       string
+		body       string
+		statusCode int
+	}{
+		{method: http.MethodGet, path: "unknown", statusCode: http.StatusNotFound},
+		{method: http.MethodGet, path: "records/example.com/A", statusCode: http.StatusNotFound},
+		{method: http.MethodGet, path: "records/example.com/A/www/unknown", statusCode: http.StatusNotFound},
+		{method: http.MethodPost, path: "records/example.com/A/www", statusCode: http.StatusMethodNotAllowed},
+		{method: http.MethodGet, path: "records/example.com/A/www/refresh", statusCode: http.StatusMethodNotAllowed},
+		{method: http.MethodPut, path: "records/example.com/A/www", body: `{"Content":""}`,
+			statusCode: http.StatusBadRequest},
+		{method: http.MethodPut, path: "records/example.com/A/www?wait=soon", body: `{}`,
+			statusCode: http.StatusBadRequest},
+		{method: http.MethodDelete, path: "records/example.com/A/www", statusCode: http.StatusAccepted},
+		{method: http.MethodPost, path: "records/example.com/A//refresh", statusCode: http.StatusAccepted},
+	}
+
+	for _, test := range tests {
+		handler := newTestHandler(&fakeEntityStore{})
+		recorder := serveApiRequest(handler, test.method, test.path, test.body)
+
+		if recorder.Code != test.statusCode {
+			t.Errorf("%s %s: got HTTP %d, want %d", test.method, test.path, recorder.Code, test.statusCode)
+		}
+	}
+}
+
+func TestApiRejectsCrossSiteChanges(t *testing.T) {
+	handler := newTestHandler(&fakeEntityStore{})
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodDelete, apiPathPrefix+"records/example.com/A/www", nil)
+	request.Header.Set("Sec-Fetch-Site", "cross-site")
+	handler.handleHttpApiRequest(recorder, request)
+
+	if recorder.Code != http.StatusForbidden {
+		t.Errorf("got HTTP %d, want 403", recorder.Code)
+	}
+}
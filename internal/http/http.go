@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"reflect"
 	"sort"
@@ -23,6 +24,12 @@ var dnsRecordTemplate = spi.TemplateInfo{
 	Styles: []string{"css/dns_record.css"},
 }
 
+var dnsRecordChangeTemplate = spi.TemplateInfo{
+	Name:   "dns_record_change",
+	Paths:  []string{"templates/dns_record_change.htmlt"},
+	Styles: []string{"css/dns_record_change.css"},
+}
+
 var statusTemplate = spi.TemplateInfo{
 	Name:   "porkbun_status",
 	Paths:  []string{"templates/porkbun_status.htmlt"},
@@ -32,10 +39,29 @@ var statusTemplate = spi.TemplateInfo{
 type Handler struct {
 	container   spi.IPMAASContainer
 	entityStore common.EntityStore
+	csrfToken   string
+	outcomes    outcomes
+	logger      *slog.Logger
+}
+
+// statusView is the header of the pages, which adds the action forms and outcome to the plugin status.
+type statusView struct {
+	*data.PluginStatus
+	CsrfToken string
+	Outcome   string
 }
 
-func NewHandler() *Handler {
-	return &Handler{}
+// dnsRecordView adds the action forms to the record data.
+type dnsRecordView struct {
+	*data.DnsRecordData
+	CsrfToken string
+}
+
+func NewHandler(logger *slog.Logger) *Handler {
+	return &Handler{
+		csrfToken: newCsrfToken(),
+		logger:    logger.With("component", "http"),
+	}
 }
 
 func (h *Handler) Init(container spi.IPMAASContainer, entityStore common.EntityStore) {
@@ -44,19 +70,27 @@ func (h *Handler) Init(container spi.IPMAASContainer, entityStore common.EntityS
 	container.ProvideContentFS(&contentFS, "content")
 	container.EnableStaticContent("static")
 	container.AddRoute("/plugins/porkbun/", h.handleHttpListRequest)
+	container.AddRoute("/plugins/porkbun/history", h.handleHttpHistoryRequest)
+	container.AddRoute("/plugins/porkbun/refresh", h.handleHttpRefreshRequest)
+	container.AddRoute("/plugins/porkbun/edit", h.handleHttpEditRequest)
+	container.AddRoute(apiPathPrefix, h.handleHttpApiRequest)
+	container.AddRoute("/plugins/porkbun/metrics", h.handleHttpMetricsRequest)
 	container.RegisterEntityRenderer(
-		reflect.TypeOf((*data.PluginStatus)(nil)).Elem(),
+		reflect.TypeOf((*statusView)(nil)).Elem(),
 		h.statusDataRendererFactory)
 	container.RegisterEntityRenderer(
 		reflect.TypeOf((*data.DnsRecordData)(nil)).Elem(),
 		h.dnsRecordDataRendererFactory)
+	container.RegisterEntityRenderer(
+		reflect.TypeOf((*data.DnsRecordChange)(nil)).Elem(),
+		h.dnsRecordChangeRendererFactory)
 }
 
 func (h *Handler) handleHttpListRequest(writer http.ResponseWriter, request *http.Request) {
 	result, err := h.entityStore.GetStatusAndEntities()
 
 	if err != nil {
-		fmt.Printf("porkbun.http handleHttpListRequest: Error retrieving entities: %s\n", err)
+		h.logger.Error("Error retrieving entities", "path", request.URL.Path, "error", err)
 		result = common.StatusAndEntities{}
 	}
 
@@ -80,8 +114,50 @@ func (h *Handler) handleHttpListRequest(writer http.ResponseWriter, request *htt
 		writer,
 		request,
 		spi.RenderListOptions{
-			Title:  "porkbun",
-			Header: &result.Status,
+			Title: "porkbun",
+			Header: &statusView{
+				PluginStatus: &result.Status,
+				CsrfToken:    h.csrfToken,
+				Outcome:      h.outcomeMessage(request),
+			},
+		},
+		entityPointers)
+}
+
+// handleHttpHistoryRequest renders the change history of the record identified by the domain, type and name query
+// parameters, newest change first.
+func (h *Handler) handleHttpHistoryRequest(writer http.ResponseWriter, request *http.Request) {
+	query := request.URL.Query()
+	domain := query.Get("domain")
+	recordType := query.Get("type")
+	name := query.Get("name")
+	result, err := h.entityStore.GetDnsRecordHistory(domain, recordType, name)
+
+	if errors.Is(err, common.ErrDnsRecordNotFound) {
+		http.Error(writer, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		h.logger.Error("Error retrieving DNS record history", "domain", domain, "type", recordType, "name", name,
+			"error", err)
+		http.Error(writer, "Unable to retrieve the DNS record history", http.StatusInternalServerError)
+		return
+	}
+
+	changeCount := len(result.Changes)
+	entityPointers := make([]any, changeCount)
+
+	for i := 0; i < changeCount; i++ {
+		entityPointers[i] = &result.Changes[changeCount-1-i]
+	}
+
+	h.container.RenderList(
+		writer,
+		request,
+		spi.RenderListOptions{
+			Title:  fmt.Sprintf("porkbun - %s %s %s history", domain, recordType, name),
+			Header: &result.Data,
 		},
 		entityPointers)
 }
@@ -96,10 +172,10 @@ func (h *Handler) statusDataRendererFactory() (spi.EntityRenderer, error) {
 
 	// Declare a function that casts the entity to the expected type and evaluates it via the template loaded above
 	renderer := func(w io.Writer, entity any) error {
-		status, ok := entity.(*data.PluginStatus)
+		status, ok := entity.(*statusView)
 
 		if !ok {
-			return errors.New("item is not an instance of *PluginStatus")
+			return errors.New("item is not an instance of *statusView")
 		}
 
 		err := template.Instance.Execute(w, status)
@@ -134,7 +210,7 @@ func (h *Handler) dnsRecordDataRendererFactory() (spi.EntityRenderer, error) {
 			return errors.New("item is not an instance of *DsnRecordData")
 		}
 
-		err := template.Instance.Execute(w, dnsRecordData)
+		err := template.Instance.Execute(w, &dnsRecordView{DnsRecordData: dnsRecordData, CsrfToken: h.csrfToken})
 
 		if err != nil {
 			return fmt.Errorf("unable to execute dnsRecord template: %w", err)
@@ -149,3 +225,35 @@ func (h *Handler) dnsRecordDataRendererFactory() (spi.EntityRenderer, error) {
 		Scripts:             template.Scripts,
 	}, nil
 }
+
+func (h *Handler) dnsRecordChangeRendererFactory() (spi.EntityRenderer, error) {
+	// Load the template
+	template, err := h.container.GetTemplate(&dnsRecordChangeTemplate)
+
+	if err != nil {
+		return spi.EntityRenderer{}, fmt.Errorf("unable to load dns_record_change template: %v", err)
+	}
+
+	// Declare a function that casts the entity to the expected type and evaluates it via the template loaded above
+	renderer := func(w io.Writer, entity any) error {
+		change, ok := entity.(*data.DnsRecordChange)
+
+		if !ok {
+			return errors.New("item is not an instance of *DnsRecordChange")
+		}
+
+		err := template.Instance.Execute(w, change)
+
+		if err != nil {
+			return fmt.Errorf("unable to execute dnsRecordChange template: %w", err)
+		}
+
+		return nil
+	}
+
+	return spi.EntityRenderer{
+		StreamingRenderFunc: renderer,
+		Styles:              template.Styles,
+		Scripts:             template.Scripts,
+	}, nil
+}
@@ -0,0 +1,283 @@
+package http
+
+import (
+	"bytes"
+	"cmp"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/data"
+)
+
+// metricsWriter writes metrics in the Prometheus text exposition format.
+type metricsWriter struct {
+	buffer bytes.Buffer
+}
+
+// family starts a metric family, writing its HELP and TYPE lines.
+func (m *metricsWriter) family(name string, metricType string, help string) {
+	_, _ = fmt.Fprintf(&m.buffer, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+// sample writes a sample, with labels given as name and value pairs.
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	m.buffer.WriteString(name)
+
+	if len(labels) > 0 {
+		m.buffer.WriteByte('{')
+
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.buffer.WriteByte(',')
+			}
+
+			m.buffer.WriteString(labels[i])
+			m.buffer.WriteString(`="`)
+			m.buffer.WriteString(escapeLabelValue(labels[i+1]))
+			m.buffer.WriteByte('"')
+		}
+
+		m.buffer.WriteByte('}')
+	}
+
+	m.buffer.WriteByte(' ')
+	m.buffer.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	m.buffer.WriteByte('\n')
+}
+
+// timestampSample writes a time as seconds since the epoch, skipping times that never happened.
+func (m *metricsWriter) timestampSample(name string, value time.Time, labels ...string) {
+	if !value.IsZero() {
+		m.sample(name, float64(value.UnixMilli())/1000, labels...)
+	}
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+// compareBool orders false before true.
+func compareBool(a bool, b bool) int {
+	return cmp.Compare(boolValue(a), boolValue(b))
+}
+
+func boolValue(value bool) float64 {
+	if value {
+		return 1
+	}
+
+	return 0
+}
+
+// handleHttpMetricsRequest exposes the plugin status, API call and record metrics in the Prometheus text format.
+func (h *Handler) handleHttpMetricsRequest(writer http.ResponseWriter, request *http.Request) {
+	if request.Method != http.MethodGet && request.Method != http.MethodHead {
+		writer.Header().Set("Allow", "GET, HEAD")
+		http.Error(writer, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	result, err := h.entityStore.GetStatusAndEntities()
+
+	if err != nil {
+		h.logger.Error("Error retrieving entities", "path", request.URL.Path, "error", err)
+		http.Error(writer, "Unable to retrieve the metrics", http.StatusInternalServerError)
+		return
+	}
+
+	slices.SortStableFunc(result.DnsRecords, func(a, b data.DnsRecordData) int {
+		return cmp.Or(
+			strings.Compare(a.Domain+" "+a.Name+" "+a.Type, b.Domain+" "+b.Name+" "+b.Type),
+			compareBool(a.Discovered, b.Discovered),
+			strings.Compare(a.Id, b.Id))
+	})
+
+	metrics := &metricsWriter{}
+	writeStatusMetrics(metrics, &result.Status)
+	writeApiMetrics(metrics, result.Status.ApiEndpoints)
+	writeDnsRecordMetrics(metrics, result.DnsRecords)
+
+	writer.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	writer.Header().Set("Content-Length", strconv.Itoa(metrics.buffer.Len()))
+	_, _ = writer.Write(metrics.buffer.Bytes())
+}
+
+func writeStatusMetrics(m *metricsWriter, status *data.PluginStatus) {
+	m.family("porkbun_queue_size", "gauge", "Number of requests waiting for a worker.")
+	m.sample("porkbun_queue_size", float64(status.CurrentQueueSize))
+	m.family("porkbun_queue_peak_size", "gauge", "Largest number of requests that waited for a worker.")
+	m.sample("porkbun_queue_peak_size", float64(status.PeakQueueSize))
+	m.family("porkbun_retry_queue_size", "gauge", "Number of failed requests waiting to be retried.")
+	m.sample("porkbun_retry_queue_size", float64(status.CurrentRetryQueueSize))
+	m.family("porkbun_retry_queue_peak_size", "gauge",
+		"Largest number of failed requests that waited to be retried.")
+	m.sample("porkbun_retry_queue_peak_size", float64(status.PeakRetryQueueSize))
+	m.family("porkbun_retry_peak_failed_attempts", "gauge",
+		"Largest number of failed attempts of a single request.")
+	m.sample("porkbun_retry_peak_failed_attempts", float64(status.PeakFailedAttempts))
+
+	m.family("porkbun_throttled_total", "counter", "Number of API calls delayed by the rate limiter.")
+	m.sample("porkbun_throttled_total", float64(status.ThrottledCount))
+	m.family("porkbun_throttled_seconds_total", "counter", "Total delay of API calls by the rate limiter.")
+	m.sample("porkbun_throttled_seconds_total", status.ThrottledTime.Seconds())
+	m.family("porkbun_rate_limited_total", "counter",
+		"Number of API responses reporting the rate limit was exceeded.")
+	m.sample("porkbun_rate_limited_total", float64(status.RateLimitedCount))
+	m.family("porkbun_rate_limited_until_timestamp_seconds", "gauge", "Time until which API calls are paused.")
+	m.timestampSample("porkbun_rate_limited_until_timestamp_seconds", status.RateLimitedUntil)
+
+	m.family("porkbun_requests_success_total", "counter", "Number of successful record operations.")
+	m.sample("porkbun_requests_success_total", float64(status.TotalSuccessCount))
+	m.family("porkbun_requests_error_total", "counter", "Number of failed record operations.")
+	m.sample("porkbun_requests_error_total", float64(status.TotalErrorCount))
+	m.family("porkbun_reconcile_total", "counter",
+		"Number of updates made to restore the configured state of records.")
+	m.sample("porkbun_reconcile_total", float64(status.TotalReconcileCount))
+	m.family("porkbun_last_error_timestamp_seconds", "gauge", "Time of the latest failed record operation.")
+	m.timestampSample("porkbun_last_error_timestamp_seconds", status.LastErrorTime)
+	m.family("porkbun_public_ip_detection_timestamp_seconds", "gauge", "Time of the latest public IP detection.")
+	m.timestampSample("porkbun_public_ip_detection_timestamp_seconds", status.PublicIpDetectionTime)
+}
+
+func writeApiMetrics(m *metricsWriter, endpoints []data.ApiEndpointStats) {
+	m.family("porkbun_api_request_duration_seconds", "histogram", "Latency of the Porkbun API calls.")
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+
+		for j, bucket := range endpoint.LatencyBuckets {
+			m.sample("porkbun_api_request_duration_seconds_bucket", float64(endpoint.LatencyCounts[j]),
+				"endpoint", endpoint.Endpoint, "le", strconv.FormatFloat(bucket.Seconds(), 'g', -1, 64))
+		}
+
+		m.sample("porkbun_api_request_duration_seconds_bucket", float64(endpoint.CallCount),
+			"endpoint", endpoint.Endpoint, "le", "+Inf")
+		m.sample("porkbun_api_request_duration_seconds_sum", endpoint.LatencySum.Seconds(),
+			"endpoint", endpoint.Endpoint)
+		m.sample("porkbun_api_request_duration_seconds_count", float64(endpoint.CallCount),
+			"endpoint", endpoint.Endpoint)
+	}
+
+	m.family("porkbun_api_responses_total", "counter", "Number of Porkbun API responses by HTTP status code.")
+
+	for i := range endpoints {
+		endpoint := &endpoints[i]
+
+		for _, code := range slices.Sorted(maps.Keys(endpoint.StatusCounts)) {
+			m.sample("porkbun_api_responses_total", float64(endpoint.StatusCounts[code]),
+				"endpoint", endpoint.Endpoint, "code", strconv.Itoa(code))
+		}
+	}
+
+	m.family("porkbun_api_transport_errors_total", "counter", "Number of Porkbun API calls that got no response.")
+
+	for i := range endpoints {
+		m.sample("porkbun_api_transport_errors_total", float64(endpoints[i].TransportErrorCount),
+			"endpoint", endpoints[i].Endpoint)
+	}
+
+	m.family("porkbun_api_last_success_timestamp_seconds", "gauge",
+		"Time of the latest successful Porkbun API call.")
+
+	for i := range endpoints {
+		m.timestampSample("porkbun_api_last_success_timestamp_seconds", endpoints[i].LastSuccessTime,
+			"endpoint", endpoints[i].Endpoint)
+	}
+}
+
+// recordLabels identifies a record.  Configured records are unique by domain, type and name, so their series survive
+// the record being recreated with a new id.  Discovered records can share their name and type, so their id is added
+// to keep their series unique.
+func recordLabels(r *data.DnsRecordData) []string {
+	labels := []string{"domain", r.Domain, "type", r.Type, "name", r.Name}
+
+	if r.Discovered {
+		labels = append(labels, "id", r.Id)
+	}
+
+	return labels
+}
+
+func writeDnsRecordMetrics(m *metricsWriter, records []data.DnsRecordData) {
+	m.family("porkbun_record_operations_total", "counter", "Number of record operations by operation and result.")
+
+	for i := range records {
+		r := &records[i]
+		labels := recordLabels(r)
+		operations := []struct {
+			operation string
+			result    string
+			count     int
+		}{
+			{"get", "success", r.GetSuccessCount},
+			{"get", "error", r.GetErrorCount},
+			{"update", "success", r.UpdateSuccessCount},
+			{"update", "error", r.UpdateErrorCount},
+			{"update", "superseded", r.UpdateSupersededCount},
+			{"delete", "success", r.DeleteSuccessCount},
+			{"delete", "error", r.DeleteErrorCount},
+		}
+
+		for _, operation := range operations {
+			m.sample("porkbun_record_operations_total", float64(operation.count),
+				slices.Concat(labels, []string{"operation", operation.operation, "result", operation.result})...)
+		}
+	}
+
+	recordMetrics := []struct {
+		name       string
+		metricType string
+		help       string
+		write      func(name string, r *data.DnsRecordData, labels []string)
+	}{
+		{"porkbun_record_reconcile_total", "counter", "Number of updates made to restore the configured state.",
+			func(name string, r *data.DnsRecordData, labels []string) {
+				m.sample(name, float64(r.ReconcileCount), labels...)
+			}},
+		{"porkbun_record_drift_total", "counter", "Number of changes made outside the plugin that were detected.",
+			func(name string, r *data.DnsRecordData, labels []string) {
+				m.sample(name, float64(r.DriftCount), labels...)
+			}},
+		{"porkbun_record_last_success_timestamp_seconds", "gauge", "Time the record was last retrieved or written.",
+			func(name string, r *data.DnsRecordData, labels []string) {
+				m.timestampSample(name, r.LastUpdateTime, labels...)
+			}},
+		{"porkbun_record_last_error_timestamp_seconds", "gauge", "Time of the latest failed operation on the record.",
+			func(name string, r *data.DnsRecordData, labels []string) {
+				m.timestampSample(name, r.LastErrorTime, labels...)
+			}},
+		{"porkbun_record_last_modified_timestamp_seconds", "gauge", "Time the record was last seen to change.",
+			func(name string, r *data.DnsRecordData, labels []string) {
+				m.timestampSample(name, r.LastModifiedTime, labels...)
+			}},
+		{"porkbun_record_next_refresh_timestamp_seconds", "gauge", "Time of the next scheduled refresh.",
+			func(name string, r *data.DnsRecordData, labels []string) {
+				m.timestampSample(name, r.NextRefreshTime, labels...)
+			}},
+		{"porkbun_record_absent", "gauge", "Whether the record is absent from its domain.",
+			func(name string, r *data.DnsRecordData, labels []string) {
+				m.sample(name, boolValue(r.Absent), labels...)
+			}},
+		{"porkbun_record_value_mismatch", "gauge",
+			"Whether the record differs from the value the plugin last tried to set.",
+			func(name string, r *data.DnsRecordData, labels []string) {
+				m.sample(name, boolValue(r.ValueMismatch), labels...)
+			}},
+	}
+
+	for _, metric := range recordMetrics {
+		m.family(metric.name, metric.metricType, metric.help)
+
+		for i := range records {
+			r := &records[i]
+			metric.write(metric.name, r, recordLabels(r))
+		}
+	}
+}
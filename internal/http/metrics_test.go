@@ -0,0 +1,55 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/avanha/pmaas-plugin-porkbun/data"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+)
+
+// fakeStatusStore returns the records from GetStatusAndEntities.
+type fakeStatusStore struct {
+	common.EntityStore
+	records []data.DnsRecordData
+}
+
+func (s *fakeStatusStore) GetStatusAndEntities() (common.StatusAndEntities, error) {
+	return common.StatusAndEntities{DnsRecords: s.records}, nil
+}
+
+func TestRecordMetricLabels(t *testing.T) {
+	handler := newTestHandler(&fakeStatusStore{records: []data.DnsRecordData{
+		{Id: "3", Domain: "example.com", Type: "TXT", Name: "www", Discovered: true, ReconcileCount: 3},
+		{Id: "2", Domain: "example.com", Type: "TXT", Name: "www", Discovered: true, ReconcileCount: 2},
+		{Id: "1", Domain: "example.com", Type: "A", Name: "www", ReconcileCount: 1},
+	}})
+	recorder := httptest.NewRecorder()
+	handler.handleHttpMetricsRequest(recorder, httptest.NewRequest(http.MethodGet, "/plugins/porkbun/metrics", nil))
+
+	var samples []string
+
+	for _, line := range strings.Split(recorder.Body.String(), "\n") {
+		if strings.HasPrefix(line, "porkbun_record_reconcile_total{") {
+			samples = append(samples, line)
+		}
+	}
+
+	expected := []string{
+		`porkbun_record_reconcile_total{domain="example.com",type="A",name="www"} 1`,
+		`porkbun_record_reconcile_total{domain="example.com",type="TXT",name="www",id="2"} 2`,
+		`porkbun_record_reconcile_total{domain="example.com",type="TXT",name="www",id="3"} 3`,
+	}
+
+	if strings.Join(samples, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("got samples\n%s\nwant\n%s", strings.Join(samples, "\n"), strings.Join(expected, "\n"))
+	}
+}
+
+func TestEscapeLabelValue(t *testing.T) {
+	if escaped := escapeLabelValue("a\\b\"c\nd"); escaped != `a\\b\"c\nd` {
+		t.Errorf("got %q, want the backslash, quote and newline escaped", escaped)
+	}
+}
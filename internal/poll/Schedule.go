@@ -0,0 +1,65 @@
+package poll
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/config"
+)
+
+// Schedule tracks when the next refresh of a record or domain is due.  It does no synchronization, so it should only
+// be used from the plugin's main goroutine.
+type Schedule struct {
+	config   config.PollConfig
+	nextTime time.Time
+}
+
+func NewSchedule(config config.PollConfig) *Schedule {
+	return &Schedule{config: config}
+}
+
+// Start schedules the first refresh, after the initial delay.
+func (s *Schedule) Start(now time.Time) {
+	s.nextTime = now.Add(s.jitter(s.config.InitialDelay))
+}
+
+// Refreshed schedules the next refresh after the interval, for a refresh made outside the schedule.
+func (s *Schedule) Refreshed(now time.Time) {
+	s.nextTime = now.Add(s.jitter(s.config.Interval))
+}
+
+// Due returns true if a refresh is due, and if so, schedules the next one after the interval.
+func (s *Schedule) Due(now time.Time) bool {
+	if s.config.Disabled || s.nextTime.IsZero() || now.Before(s.nextTime) {
+		return false
+	}
+
+	s.Refreshed(now)
+
+	return true
+}
+
+// Failed brings the next refresh forward to the failure interval, if that is sooner.
+func (s *Schedule) Failed(now time.Time) {
+	if s.config.FailureInterval <= 0 {
+		return
+	}
+
+	if followUpTime := now.Add(s.jitter(s.config.FailureInterval)); followUpTime.Before(s.nextTime) {
+		s.nextTime = followUpTime
+	}
+}
+
+// NextTime returns the time of the next refresh, or the zero time if polling is disabled or not started.
+func (s *Schedule) NextTime() time.Time {
+	if s.config.Disabled {
+		return time.Time{}
+	}
+
+	return s.nextTime
+}
+
+// jitter spreads the passed delay uniformly across [delay * (1 - jitter), delay * (1 + jitter)].
+func (s *Schedule) jitter(delay time.Duration) time.Duration {
+	return time.Duration(float64(delay) * (1 + s.config.Jitter*(2*rand.Float64()-1)))
+}
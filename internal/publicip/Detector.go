@@ -0,0 +1,174 @@
+package publicip
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+	spicommon "github.com/avanha/pmaas-spi/common"
+)
+
+// Result holds the public addresses found by a detection.  Addresses that weren't found are not valid.
+type Result struct {
+	IpV4          netip.Addr
+	IpV6          netip.Addr
+	DetectionTime time.Time
+	Error         error
+}
+
+// httpRequestDoer is implemented by HTTP clients, like http.Client, that can send requests with a context.
+type httpRequestDoer interface {
+	Do(request *http.Request) (*http.Response, error)
+}
+
+// Detector finds the public IP addresses of the host by calling the Porkbun ping endpoint, which echoes the caller's
+// address, and any additional IP echo URLs.
+type Detector struct {
+	enqueueRequest func(request common.Request) error
+	pingUrls       []string
+	echoUrls       []string
+	httpClient     spicommon.HttpClient
+	timeout        time.Duration
+}
+
+// NewDetector creates a detector that sends ping requests for each of the ping URLs to the workers, via
+// enqueueRequest, so they share the rate limit, error handling and metrics of the other API calls.  Each of the echo
+// URLs is called with a GET, and must respond with the address as plain text.  Each call is abandoned after timeout.
+func NewDetector(
+	enqueueRequest func(request common.Request) error,
+	pingUrls []string,
+	echoUrls []string,
+	httpClient spicommon.HttpClient,
+	timeout time.Duration) *Detector {
+	return &Detector{
+		enqueueRequest: enqueueRequest,
+		pingUrls:       pingUrls,
+		echoUrls:       echoUrls,
+		httpClient:     httpClient,
+		timeout:        timeout,
+	}
+}
+
+// Detect calls all the configured URLs and returns the first IPv4 and IPv6 addresses found.  The error in the result
+// is only set if no address was found.
+func (d *Detector) Detect(ctx context.Context) Result {
+	result := Result{DetectionTime: time.Now()}
+	errs := make([]error, 0)
+
+	for _, url := range d.pingUrls {
+		addr, err := d.ping(ctx, url)
+		errs = result.add(addr, err, url, errs)
+	}
+
+	for _, url := range d.echoUrls {
+		addr, err := d.echo(ctx, url)
+		errs = result.add(addr, err, url, errs)
+	}
+
+	if !result.IpV4.IsValid() && !result.IpV6.IsValid() {
+		result.Error = fmt.Errorf("unable to detect public IP address: %w", errors.Join(errs...))
+	}
+
+	return result
+}
+
+func (r *Result) add(addr netip.Addr, err error, url string, errs []error) []error {
+	if err != nil {
+		return append(errs, fmt.Errorf("%s: %w", url, err))
+	}
+
+	if addr.Is4() && !r.IpV4.IsValid() {
+		r.IpV4 = addr
+	} else if addr.Is6() && !r.IpV6.IsValid() {
+		r.IpV6 = addr
+	}
+
+	return errs
+}
+
+func (d *Detector) ping(ctx context.Context, url string) (netip.Addr, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	// Buffered, so the worker doesn't block on the result if the ping was abandoned
+	resultCh := make(chan common.DnsRecordResult, 1)
+	err := d.enqueueRequest(common.Request{
+		RequestType: common.RequestTypePing,
+		ResultCh:    resultCh,
+		PingRequest: common.PingRequest{Url: url},
+		Context:     ctx,
+	})
+
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("unable to enqueue ping request: %w", err)
+	}
+
+	select {
+	case result := <-resultCh:
+		if result.Error != nil {
+			return netip.Addr{}, result.Error
+		}
+
+		return parsePublicAddr(result.PublicIp)
+	case <-ctx.Done():
+		return netip.Addr{}, fmt.Errorf("ping abandoned: %w", context.Cause(ctx))
+	}
+}
+
+func (d *Detector) echo(ctx context.Context, url string) (netip.Addr, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.timeout)
+	defer cancel()
+	response, err := d.get(ctx, url)
+
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("http get failed: %w", err)
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return netip.Addr{}, fmt.Errorf("unexpected HTTP status %d", response.StatusCode)
+	}
+
+	// Addresses are short, anything longer is not an address
+	responseBytes, err := io.ReadAll(io.LimitReader(response.Body, 128))
+
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("error reading response body: %w", err)
+	}
+
+	return parsePublicAddr(strings.TrimSpace(string(responseBytes)))
+}
+
+// get sends a GET request with the context, if the client supports it.  Other clients can't be interrupted, so they
+// must apply their own timeout.
+func (d *Detector) get(ctx context.Context, url string) (*http.Response, error) {
+	doer, ok := d.httpClient.(httpRequestDoer)
+
+	if !ok {
+		return d.httpClient.Get(url)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return doer.Do(request)
+}
+
+func parsePublicAddr(value string) (netip.Addr, error) {
+	addr, err := netip.ParseAddr(value)
+
+	if err != nil {
+		return netip.Addr{}, fmt.Errorf("invalid address %q: %w", value, err)
+	}
+
+	// Unmap IPv4-mapped IPv6 addresses, so they're treated as IPv4
+	return addr.Unmap(), nil
+}
@@ -0,0 +1,301 @@
+package validation
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+)
+
+// MinTtl is the lowest TTL accepted by the Porkbun API.  A TTL of zero lets the API apply its default.
+const MinTtl = 600
+
+const maxTxtLength = 4096
+
+var supportedTypes = map[string]bool{
+	"A":     true,
+	"AAAA":  true,
+	"CNAME": true,
+	"ALIAS": true,
+	"MX":    true,
+	"TXT":   true,
+	"NS":    true,
+	"SRV":   true,
+	"TLSA":  true,
+	"CAA":   true,
+	"HTTPS": true,
+	"SVCB":  true,
+	"SSHFP": true,
+}
+
+// ValidateType returns an error if the record type is not supported by the Porkbun API.
+func ValidateType(recordType string) error {
+	if !supportedTypes[recordType] {
+		return fmt.Errorf("unsupported record type %q", recordType)
+	}
+
+	return nil
+}
+
+// ValidateName returns an error if the name, relative to the domain, is not a valid record name.  An empty name
+// refers to the domain apex, and the first label may be a "*" wildcard.
+func ValidateName(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	if strings.HasPrefix(name, "*.") {
+		name = strings.TrimPrefix(name, "*.")
+	} else if name == "*" {
+		return nil
+	}
+
+	if err := validateHostname(name); err != nil {
+		return fmt.Errorf("invalid record name: %w", err)
+	}
+
+	return nil
+}
+
+// ValidateTtl returns an error if the TTL is outside the range accepted by the Porkbun API.
+func ValidateTtl(ttl int32) error {
+	if ttl != 0 && ttl < MinTtl {
+		return fmt.Errorf("TTL %d is below the minimum of %d seconds", ttl, MinTtl)
+	}
+
+	return nil
+}
+
+// ValidatePriority returns an error if the priority is not a 16-bit unsigned integer.
+func ValidatePriority(priority int32) error {
+	if priority < 0 || priority > 65535 {
+		return fmt.Errorf("priority %d is not between 0 and 65535", priority)
+	}
+
+	return nil
+}
+
+// ValidateContent returns an error if the content is not valid for the record type.  The priority of MX, SRV,
+// HTTPS and SVCB records is not part of the content, except for the SvcPriority of HTTPS and SVCB records.
+func ValidateContent(recordType string, content string) error {
+	if err := ValidateType(recordType); err != nil {
+		return err
+	}
+
+	if content == "" {
+		return fmt.Errorf("%s record value must not be empty", recordType)
+	}
+
+	var err error
+
+	switch recordType {
+	case "A":
+		err = validateAddress(content, true)
+	case "AAAA":
+		err = validateAddress(content, false)
+	case "CNAME", "ALIAS", "NS", "MX":
+		err = validateHostname(strings.TrimSuffix(content, "."))
+	case "TXT":
+		if len(content) > maxTxtLength {
+			err = fmt.Errorf("value is longer than %d characters", maxTxtLength)
+		}
+	case "SRV":
+		err = validateSrv(content)
+	case "TLSA":
+		err = validateTlsa(content)
+	case "CAA":
+		err = validateCaa(content)
+	case "HTTPS", "SVCB":
+		err = validateSvcb(content)
+	case "SSHFP":
+		err = validateSshfp(content)
+	}
+
+	if err != nil {
+		return fmt.Errorf("invalid %s record value %q: %w", recordType, content, err)
+	}
+
+	return nil
+}
+
+func validateAddress(content string, ipV4 bool) error {
+	addr, err := netip.ParseAddr(content)
+
+	if err != nil {
+		return err
+	}
+
+	if ipV4 && !addr.Is4() {
+		return fmt.Errorf("not an IPv4 address")
+	}
+
+	if !ipV4 && (!addr.Is6() || addr.Is4In6()) {
+		return fmt.Errorf("not an IPv6 address")
+	}
+
+	// A zone, like the interface in fe80::1%eth0, only has meaning on the host
+	if addr.Zone() != "" {
+		return fmt.Errorf("address must not have a zone")
+	}
+
+	return nil
+}
+
+func validateHostname(hostname string) error {
+	if len(hostname) == 0 || len(hostname) > 253 {
+		return fmt.Errorf("hostname must be between 1 and 253 characters")
+	}
+
+	for _, label := range strings.Split(hostname, ".") {
+		if len(label) == 0 || len(label) > 63 {
+			return fmt.Errorf("label %q must be between 1 and 63 characters", label)
+		}
+
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("label %q must not start or end with a hyphen", label)
+		}
+
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+				return fmt.Errorf("label %q contains invalid character %q", label, c)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateTarget validates the target of SRV, HTTPS and SVCB records, where "." means "no target" or "the owner name"
+func validateTarget(target string) error {
+	if target == "." {
+		return nil
+	}
+
+	return validateHostname(strings.TrimSuffix(target, "."))
+}
+
+func validateUint(field string, value string, max uint64) error {
+	parsed, err := strconv.ParseUint(value, 10, 64)
+
+	if err != nil {
+		return fmt.Errorf("%s %q is not a number", field, value)
+	}
+
+	if parsed > max {
+		return fmt.Errorf("%s %s is greater than %d", field, value, max)
+	}
+
+	return nil
+}
+
+// validateSrv validates "weight port target", the priority is set separately
+func validateSrv(content string) error {
+	fields := strings.Fields(content)
+
+	if len(fields) != 3 {
+		return fmt.Errorf("expected \"weight port target\"")
+	}
+
+	if err := validateUint("weight", fields[0], 65535); err != nil {
+		return err
+	}
+
+	if err := validateUint("port", fields[1], 65535); err != nil {
+		return err
+	}
+
+	return validateTarget(fields[2])
+}
+
+// validateTlsa validates "usage selector matching-type certificate-data"
+func validateTlsa(content string) error {
+	fields := strings.Fields(content)
+
+	if len(fields) != 4 {
+		return fmt.Errorf("expected \"usage selector matching-type data\"")
+	}
+
+	if err := validateUint("usage", fields[0], 3); err != nil {
+		return err
+	}
+
+	if err := validateUint("selector", fields[1], 1); err != nil {
+		return err
+	}
+
+	if err := validateUint("matching type", fields[2], 2); err != nil {
+		return err
+	}
+
+	if _, err := hex.DecodeString(fields[3]); err != nil {
+		return fmt.Errorf("certificate data is not hexadecimal: %w", err)
+	}
+
+	return nil
+}
+
+// validateSshfp validates "algorithm fingerprint-type fingerprint"
+func validateSshfp(content string) error {
+	fields := strings.Fields(content)
+
+	if len(fields) != 3 {
+		return fmt.Errorf("expected \"algorithm type fingerprint\"")
+	}
+
+	if err := validateUint("algorithm", fields[0], 255); err != nil {
+		return err
+	}
+
+	if err := validateUint("fingerprint type", fields[1], 255); err != nil {
+		return err
+	}
+
+	if _, err := hex.DecodeString(fields[2]); err != nil {
+		return fmt.Errorf("fingerprint is not hexadecimal: %w", err)
+	}
+
+	return nil
+}
+
+// validateCaa validates "flags tag value"
+func validateCaa(content string) error {
+	fields := strings.SplitN(content, " ", 3)
+
+	if len(fields) != 3 {
+		return fmt.Errorf("expected \"flags tag value\"")
+	}
+
+	if err := validateUint("flags", fields[0], 255); err != nil {
+		return err
+	}
+
+	tag := fields[1]
+
+	if tag == "" {
+		return fmt.Errorf("tag must not be empty")
+	}
+
+	for _, c := range tag {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			return fmt.Errorf("tag %q must be alphanumeric", tag)
+		}
+	}
+
+	return nil
+}
+
+// validateSvcb validates "priority target [params...]"
+func validateSvcb(content string) error {
+	fields := strings.Fields(content)
+
+	if len(fields) < 2 {
+		return fmt.Errorf("expected \"priority target [params]\"")
+	}
+
+	if err := validateUint("priority", fields[0], 65535); err != nil {
+		return err
+	}
+
+	return validateTarget(fields[1])
+}
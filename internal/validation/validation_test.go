@@ -0,0 +1,136 @@
+package validation
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateType(t *testing.T) {
+	for _, recordType := range []string{"A", "AAAA", "CNAME", "ALIAS", "MX", "TXT", "NS", "SRV", "TLSA", "CAA",
+		"HTTPS", "SVCB", "SSHFP"} {
+		if err := ValidateType(recordType); err != nil {
+			t.Errorf("%s: unexpected error: %v", recordType, err)
+		}
+	}
+
+	for _, recordType := range []string{"", "a", "PTR", "SOA"} {
+		if err := ValidateType(recordType); err == nil {
+			t.Errorf("%q: got no error, want an unsupported type error", recordType)
+		}
+	}
+}
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "", valid: true},
+		{name: "www", valid: true},
+		{name: "a.b-c.d", valid: true},
+		{name: "_acme-challenge", valid: true},
+		{name: "*", valid: true},
+		{name: "*.www", valid: true},
+		{name: "www.*", valid: false},
+		{name: "-www", valid: false},
+		{name: "www-", valid: false},
+		{name: "a..b", valid: false},
+		{name: "www.", valid: false},
+		{name: "ww w", valid: false},
+		{name: strings.Repeat("a", 64), valid: false},
+		{name: strings.Repeat("a.", 127) + "a", valid: false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateName(test.name); (err == nil) != test.valid {
+			t.Errorf("%q: got error %v, want valid %t", test.name, err, test.valid)
+		}
+	}
+}
+
+func TestValidateTtl(t *testing.T) {
+	tests := []struct {
+		ttl   int32
+		valid bool
+	}{
+		{ttl: 0, valid: true},
+		{ttl: MinTtl, valid: true},
+		{ttl: 86400, valid: true},
+		{ttl: MinTtl - 1, valid: false},
+		{ttl: -1, valid: false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateTtl(test.ttl); (err == nil) != test.valid {
+			t.Errorf("%d: got error %v, want valid %t", test.ttl, err, test.valid)
+		}
+	}
+}
+
+func TestValidatePriority(t *testing.T) {
+	tests := []struct {
+		priority int32
+		valid    bool
+	}{
+		{priority: 0, valid: true},
+		{priority: 65535, valid: true},
+		{priority: -1, valid: false},
+		{priority: 65536, valid: false},
+	}
+
+	for _, test := range tests {
+		if err := ValidatePriority(test.priority); (err == nil) != test.valid {
+			t.Errorf("%d: got error %v, want valid %t", test.priority, err, test.valid)
+		}
+	}
+}
+
+func TestValidateContent(t *testing.T) {
+	tests := []struct {
+		recordType string
+		content    string
+		valid      bool
+	}{
+		{recordType: "A", content: "192.0.2.1", valid: true},
+		{recordType: "A", content: "", valid: false},
+		{recordType: "A", content: "2001:db8::1", valid: false},
+		{recordType: "A", content: "192.0.2", valid: false},
+		{recordType: "AAAA", content: "2001:db8::1", valid: true},
+		{recordType: "AAAA", content: "192.0.2.1", valid: false},
+		{recordType: "AAAA", content: "::ffff:192.0.2.1", valid: false},
+		{recordType: "AAAA", content: "fe80::1%eth0", valid: false},
+		{recordType: "CNAME", content: "www.example.com", valid: true},
+		{recordType: "CNAME", content: "www.example.com.", valid: true},
+		{recordType: "CNAME", content: "www example", valid: false},
+		{recordType: "ALIAS", content: "example.net", valid: true},
+		{recordType: "NS", content: "ns1.example.net", valid: true},
+		{recordType: "MX", content: "mail.example.com", valid: true},
+		{recordType: "MX", content: "10 mail.example.com", valid: false},
+		{recordType: "TXT", content: "v=spf1 -all", valid: true},
+		{recordType: "TXT", content: strings.Repeat("a", maxTxtLength+1), valid: false},
+		{recordType: "SRV", content: "5 5060 sip.example.com", valid: true},
+		{recordType: "SRV", content: "0 0 .", valid: true},
+		{recordType: "SRV", content: "5 70000 sip.example.com", valid: false},
+		{recordType: "SRV", content: "5 sip.example.com", valid: false},
+		{recordType: "TLSA", content: "3 1 1 0123456789abcdef", valid: true},
+		{recordType: "TLSA", content: "4 1 1 0123456789abcdef", valid: false},
+		{recordType: "TLSA", content: "3 1 1 xyz", valid: false},
+		{recordType: "CAA", content: "0 issue \"letsencrypt.org\"", valid: true},
+		{recordType: "CAA", content: "0 is-sue \"letsencrypt.org\"", valid: false},
+		{recordType: "CAA", content: "256 issue \"letsencrypt.org\"", valid: false},
+		{recordType: "HTTPS", content: "1 . alpn=h2,h3", valid: true},
+		{recordType: "SVCB", content: "0 svc.example.com", valid: true},
+		{recordType: "SVCB", content: "1", valid: false},
+		{recordType: "SSHFP", content: "4 2 0123456789abcdef", valid: true},
+		{recordType: "SSHFP", content: "4 2", valid: false},
+		{recordType: "SSHFP", content: "4 2 xyz", valid: false},
+		{recordType: "SSHFP", content: "four 2 0123456789abcdef", valid: false},
+		{recordType: "PTR", content: "host.example.com", valid: false},
+	}
+
+	for _, test := range tests {
+		if err := ValidateContent(test.recordType, test.content); (err == nil) != test.valid {
+			t.Errorf("%s %q: got error %v, want valid %t", test.recordType, test.content, err, test.valid)
+		}
+	}
+}
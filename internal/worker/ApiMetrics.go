@@ -0,0 +1,103 @@
+package worker
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/data"
+)
+
+// apiLatencyBuckets are the upper bounds of the API call latency histogram buckets.
+var apiLatencyBuckets = []time.Duration{
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+}
+
+// ApiMetrics collects the latency and HTTP status codes of the API calls, per endpoint.  It is shared by the workers.
+type ApiMetrics struct {
+	mutex     sync.Mutex
+	endpoints map[string]*data.ApiEndpointStats
+}
+
+func NewApiMetrics() *ApiMetrics {
+	return &ApiMetrics{
+		endpoints: make(map[string]*data.ApiEndpointStats),
+	}
+}
+
+// observe records a call to the endpoint.  A statusCode of zero means the call failed without a response.
+func (m *ApiMetrics) observe(endpoint string, statusCode int, latency time.Duration, success bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	name := apiEndpointName(endpoint)
+	stats, ok := m.endpoints[name]
+
+	if !ok {
+		stats = &data.ApiEndpointStats{
+			Endpoint:       name,
+			LatencyBuckets: apiLatencyBuckets,
+			LatencyCounts:  make([]int, len(apiLatencyBuckets)),
+			StatusCounts:   make(map[int]int),
+		}
+		m.endpoints[name] = stats
+	}
+
+	for i, bucket := range apiLatencyBuckets {
+		if latency <= bucket {
+			stats.LatencyCounts[i]++
+		}
+	}
+
+	stats.LatencySum += latency
+	stats.CallCount++
+
+	if statusCode == 0 {
+		stats.TransportErrorCount++
+	} else {
+		stats.StatusCounts[statusCode]++
+	}
+
+	if success {
+		stats.LastSuccessTime = time.Now()
+	}
+}
+
+// Stats returns a copy of the stats of each endpoint called so far, ordered by endpoint.
+func (m *ApiMetrics) Stats() []data.ApiEndpointStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	result := make([]data.ApiEndpointStats, 0, len(m.endpoints))
+
+	for _, name := range slices.Sorted(maps.Keys(m.endpoints)) {
+		stats := *m.endpoints[name]
+		stats.LatencyCounts = slices.Clone(stats.LatencyCounts)
+		stats.StatusCounts = maps.Clone(stats.StatusCounts)
+		result = append(result, stats)
+	}
+
+	return result
+}
+
+// apiEndpointName strips the domain and record parameters from an endpoint, e.g. dns/edit/example.com/123 becomes
+// dns/edit, so the number of endpoints stays bounded.
+func apiEndpointName(endpoint string) string {
+	segments := strings.SplitN(endpoint, "/", 3)
+
+	if len(segments) > 2 {
+		segments = segments[:2]
+	}
+
+	return strings.Join(segments, "/")
+}
@@ -1,6 +1,40 @@
 package worker
 
+import (
+	"log/slog"
+
+	"github.com/avanha/pmaas-plugin-porkbun/credentials"
+)
+
 type CredsMessage struct {
 	SecretApiKey string `json:"secretapikey"`
 	ApiKey       string `json:"apikey"`
 }
+
+// credentialedMessage is a request body that carries the API credentials, which are set just before it is sent.
+type credentialedMessage interface {
+	setCredentials(apiCredentials credentials.Credentials)
+}
+
+func (m *CredsMessage) setCredentials(apiCredentials credentials.Credentials) {
+	m.ApiKey = apiCredentials.ApiKey
+	m.SecretApiKey = apiCredentials.ApiSecret
+}
+
+// String keeps the credentials out of messages formatted with %v and %s.  The request messages embedding a
+// CredsMessage are formatted the same way, so their bodies never reach the log either.
+func (m CredsMessage) String() string {
+	return "{ApiKey:" + credentials.Redacted + " SecretApiKey:" + credentials.Redacted + "}"
+}
+
+// GoString keeps the credentials out of messages formatted with %#v.
+func (m CredsMessage) GoString() string {
+	return "worker.CredsMessage" + m.String()
+}
+
+// LogValue keeps the credentials out of structured log entries.
+func (m CredsMessage) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("apikey", credentials.Redacted),
+		slog.String("secretapikey", credentials.Redacted))
+}
@@ -1,10 +1,21 @@
 package worker
 
+import "encoding/json"
+
+// apiResponse is implemented by all response messages, via the embedded StatusMessage
+type apiResponse interface {
+	statusMessage() *StatusMessage
+}
+
 type StatusMessage struct {
 	Status  string `json:"status"`
 	Message string `json:"message"`
 }
 
+func (m *StatusMessage) statusMessage() *StatusMessage {
+	return m
+}
+
 type RetrieveDnsRerecordResponseMessage struct {
 	StatusMessage
 	Records []ResponseDnsRecordMessage `json:"records"`
@@ -20,7 +31,9 @@ type DnsRecordMessage struct {
 	Content string `json:"content"`
 	Ttl     string `json:"ttl,omitempty"`
 	Prio    string `json:"prio,omitempty"`
-	Notes   string `json:"notes,omitempty"`
+	// Notes is always sent, since the edit endpoint keeps the notes of the record when the field is omitted, which
+	// would prevent clearing them
+	Notes string `json:"notes"`
 }
 
 type ResponseDnsRecordMessage struct {
@@ -32,3 +45,19 @@ type EditDnsRecordRequestMessage struct {
 	CredsMessage
 	DnsRecordMessage
 }
+
+type CreateDnsRecordRequestMessage struct {
+	CredsMessage
+	DnsRecordMessage
+}
+
+type PingResponseMessage struct {
+	StatusMessage
+	YourIp string `json:"yourIp"`
+}
+
+type CreateDnsRecordResponseMessage struct {
+	StatusMessage
+	// The API returns the id as a number, while retrieval returns it as a string.
+	Id json.Number `json:"id"`
+}
@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RateLimiter is a token bucket shared by the workers, which limits the rate of API calls.  It is also paused when the
+// API responds that the rate limit was exceeded.
+type RateLimiter struct {
+	mutex               sync.Mutex
+	rate                float64
+	burst               float64
+	tokens              float64
+	lastRefillTime      time.Time
+	pausedUntil         time.Time
+	throttledCount      int
+	throttledTime       time.Duration
+	rateLimitedCount    int
+	lastRateLimitedTime time.Time
+	logger              *slog.Logger
+}
+
+// RateLimiterStats reports how much the API calls were delayed by the rate limiter.
+type RateLimiterStats struct {
+	// ThrottledCount is the number of calls that were delayed
+	ThrottledCount int
+	// ThrottledTime is the sum of the delays of all calls
+	ThrottledTime time.Duration
+	// RateLimitedCount is the number of responses indicating that the API rate limit was exceeded
+	RateLimitedCount    int
+	LastRateLimitedTime time.Time
+	PausedUntil         time.Time
+}
+
+// NewRateLimiter returns a RateLimiter that allows requestsPerSecond calls on average, and bursts of up to burst
+// calls.  A requestsPerSecond of zero or less only pauses calls when the API reports that the limit was exceeded.
+func NewRateLimiter(requestsPerSecond float64, burst int, logger *slog.Logger) *RateLimiter {
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	return &RateLimiter{
+		rate:           requestsPerSecond,
+		burst:          math.Max(float64(burst), 1),
+		tokens:         math.Max(float64(burst), 1),
+		lastRefillTime: time.Now(),
+		logger:         logger,
+	}
+}
+
+// Wait blocks until the call is allowed, or ctx is done.
+func (l *RateLimiter) Wait(ctx context.Context) error {
+	delay := l.reserve()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reserve takes a token, and returns how long the caller must wait before the call.  Tokens can be taken ahead of
+// time, so concurrent callers are spread out rather than woken together.
+func (l *RateLimiter) reserve() time.Duration {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	var delay time.Duration
+
+	if l.rate > 0 {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.lastRefillTime).Seconds()*l.rate)
+		l.lastRefillTime = now
+		l.tokens--
+
+		if l.tokens < 0 {
+			delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+		}
+	}
+
+	if pauseDelay := l.pausedUntil.Sub(now); pauseDelay > delay {
+		delay = pauseDelay
+	}
+
+	if delay > 0 {
+		l.throttledCount++
+		l.throttledTime += delay
+	}
+
+	return delay
+}
+
+// RateLimited records a response indicating that the API rate limit was exceeded, and pauses all calls for
+// retryAfter, if it is set.
+func (l *RateLimiter) RateLimited(retryAfter time.Duration) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	l.rateLimitedCount++
+	l.lastRateLimitedTime = now
+
+	if pausedUntil := now.Add(retryAfter); pausedUntil.After(l.pausedUntil) {
+		l.logger.Warn("Rate limit exceeded, pausing API calls", "retry_after", retryAfter)
+		l.pausedUntil = pausedUntil
+	}
+}
+
+func (l *RateLimiter) Stats() RateLimiterStats {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	return RateLimiterStats{
+		ThrottledCount:      l.throttledCount,
+		ThrottledTime:       l.throttledTime,
+		RateLimitedCount:    l.rateLimitedCount,
+		LastRateLimitedTime: l.lastRateLimitedTime,
+		PausedUntil:         l.pausedUntil,
+	}
+}
+
+// parseRetryAfter returns the delay specified by the Retry-After header of a response, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(header http.Header, now time.Time, logger *slog.Logger) time.Duration {
+	value := header.Get("Retry-After")
+
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	logger.Warn("Ignoring invalid Retry-After header", "retry_after", value)
+
+	return 0
+}
@@ -0,0 +1,111 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func TestRateLimiterAllowsBurst(t *testing.T) {
+	limiter := NewRateLimiter(1, 3, discardLogger)
+
+	for i := range 3 {
+		if delay := limiter.reserve(); delay > 0 {
+			t.Errorf("call %d: got delay %v, want none within the burst", i, delay)
+		}
+	}
+
+	if delay := limiter.reserve(); delay <= 0 || delay > time.Second {
+		t.Errorf("got delay %v, want up to 1s after the burst", delay)
+	}
+
+	if stats := limiter.Stats(); stats.ThrottledCount != 1 {
+		t.Errorf("got throttled count %d, want 1", stats.ThrottledCount)
+	}
+}
+
+func TestRateLimiterSpreadsCalls(t *testing.T) {
+	limiter := NewRateLimiter(10, 1, discardLogger)
+	limiter.reserve()
+	first := limiter.reserve()
+	second := limiter.reserve()
+
+	// Reserved tokens are taken ahead of time, so each caller waits one interval longer than the previous one
+	if difference := second - first; difference < 90*time.Millisecond || difference > 110*time.Millisecond {
+		t.Errorf("got delays %v and %v, want them 100ms apart", first, second)
+	}
+}
+
+func TestRateLimiterWithoutRate(t *testing.T) {
+	limiter := NewRateLimiter(0, 0, discardLogger)
+
+	for range 100 {
+		if delay := limiter.reserve(); delay > 0 {
+			t.Fatalf("got delay %v, want none without a rate", delay)
+		}
+	}
+}
+
+func TestRateLimiterPause(t *testing.T) {
+	limiter := NewRateLimiter(0, 0, discardLogger)
+	limiter.RateLimited(time.Minute)
+
+	if delay := limiter.reserve(); delay < 59*time.Second {
+		t.Errorf("got delay %v, want about a minute while paused", delay)
+	}
+
+	// A shorter pause doesn't shorten the current one
+	limiter.RateLimited(time.Second)
+	stats := limiter.Stats()
+
+	if stats.RateLimitedCount != 2 || time.Until(stats.PausedUntil) < 59*time.Second {
+		t.Errorf("got %+v, want 2 rate limited responses and the original pause", stats)
+	}
+}
+
+func TestRateLimiterWaitCanceled(t *testing.T) {
+	limiter := NewRateLimiter(0, 0, discardLogger)
+	limiter.RateLimited(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if err := limiter.Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+	}{
+		{name: "missing", value: "", expected: 0},
+		{name: "seconds", value: "120", expected: 2 * time.Minute},
+		{name: "negative seconds", value: "-5", expected: 0},
+		{name: "date", value: now.Add(30 * time.Second).Format(http.TimeFormat), expected: 30 * time.Second},
+		{name: "past date", value: now.Add(-time.Hour).Format(http.TimeFormat), expected: 0},
+		{name: "invalid", value: "soon", expected: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := http.Header{}
+
+			if test.value != "" {
+				header.Set("Retry-After", test.value)
+			}
+
+			if delay := parseRetryAfter(header, now, discardLogger); delay != test.expected {
+				t.Errorf("got %v, want %v", delay, test.expected)
+			}
+		})
+	}
+}
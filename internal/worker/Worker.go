@@ -7,47 +7,67 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strconv"
 	"strings"
 	"sync/atomic"
 	"time"
 
+	"github.com/avanha/pmaas-plugin-porkbun/credentials"
 	"github.com/avanha/pmaas-plugin-porkbun/data"
 	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
 	spicommon "github.com/avanha/pmaas-spi/common"
 )
 
 type Worker struct {
-	ApiKey          string
-	ApiSecret       string
-	credentialsBody []byte
-	httpClient      spicommon.HttpClient
-	requestCh       chan common.Request
-	err             atomic.Value
+	credentials credentials.Provider
+	apiBaseUrl  string
+	httpClient  spicommon.HttpClient
+	requestCh   chan common.Request
+	rateLimiter *RateLimiter
+	apiMetrics  *ApiMetrics
+	logger      *slog.Logger
+	// requestLogger adds the attributes of the request being processed to the entries of logger
+	requestLogger *slog.Logger
+	runCtx        context.Context
+	// requestCtx is canceled when the worker is stopped, or when the context of the request being processed is
+	requestCtx context.Context
+	err        atomic.Value
 }
 
-func NewPorkBunWorker(apiKey string, apiSecret string, requestCh chan common.Request) *Worker {
-	return &Worker{
-		ApiKey:     apiKey,
-		ApiSecret:  apiSecret,
-		httpClient: &spicommon.DefaultHttpClient{},
-		requestCh:  requestCh,
+func NewPorkBunWorker(
+	credentialsProvider credentials.Provider,
+	apiBaseUrl string,
+	httpClient spicommon.HttpClient,
+	requestCh chan common.Request,
+	rateLimiter *RateLimiter,
+	apiMetrics *ApiMetrics,
+	logger *slog.Logger) *Worker {
+	if httpClient == nil {
+		httpClient = &spicommon.DefaultHttpClient{}
 	}
-}
 
-func (w *Worker) Run(ctx context.Context) {
-	credentialsBodyBytes, err := json.Marshal(CredsMessage{
-		ApiKey:       w.ApiKey,
-		SecretApiKey: w.ApiSecret,
-	})
+	if logger == nil {
+		logger = slog.Default()
+	}
 
-	if err == nil {
-		w.credentialsBody = credentialsBodyBytes
-	} else {
-		fmt.Printf("Unable to serialize credentials: %s\n", err)
+	return &Worker{
+		credentials:   credentialsProvider,
+		apiBaseUrl:    strings.TrimSuffix(apiBaseUrl, "/"),
+		httpClient:    httpClient,
+		requestCh:     requestCh,
+		rateLimiter:   rateLimiter,
+		apiMetrics:    apiMetrics,
+		logger:        logger,
+		requestLogger: logger,
 	}
+}
 
+func (w *Worker) Run(ctx context.Context) {
+	// Allows API calls to stop waiting for the rate limiter when the worker is stopped
+	w.runCtx = ctx
+	w.requestCtx = ctx
 	for run := true; run; {
 		select {
 		case <-ctx.Done():
@@ -85,7 +105,23 @@ func (w *Worker) cancelRequest(request *common.Request) {
 }
 
 func (w *Worker) processRequest(request *common.Request) {
-	fmt.Printf("%T Received request, type %d\n", w, request.RequestType)
+	w.requestLogger = w.logger.With(request.LogArgs()...)
+	w.requestLogger.Debug("Received request")
+
+	if request.Context != nil && request.Context.Err() != nil {
+		w.completeDnsRecordRequestWithError(
+			request.ResultCh, context.Cause(request.Context), "DNS record request abandoned")
+		return
+	}
+
+	if request.Context != nil {
+		ctx, cancel := context.WithCancel(w.runCtx)
+		defer cancel()
+		defer context.AfterFunc(request.Context, cancel)()
+		w.requestCtx = ctx
+		defer func() { w.requestCtx = w.runCtx }()
+	}
+
 	switch request.RequestType {
 	case common.RequestTypeGetDnsRecord:
 		w.processGetDnsRecordRequest(&request.GetDnsRecordRequest, request.ResultCh)
@@ -93,6 +129,15 @@ func (w *Worker) processRequest(request *common.Request) {
 	case common.RequestTypeUpdateDnsRecord:
 		w.processUpdateDnsRecordRequest(&request.UpdateDnsRecordRequest, request.ResultCh)
 		break
+	case common.RequestTypeDeleteDnsRecord:
+		w.processDeleteDnsRecordRequest(&request.DeleteDnsRecordRequest, request.ResultCh)
+		break
+	case common.RequestTypeRetrieveDomain:
+		w.processRetrieveDomainRequest(&request.RetrieveDomainRequest, request.ResultCh)
+		break
+	case common.RequestTypePing:
+		w.processPingRequest(&request.PingRequest, request.ResultCh)
+		break
 	}
 }
 
@@ -101,8 +146,40 @@ func (w *Worker) processGetDnsRecordRequest(
 	resultCh chan common.DnsRecordResult) {
 	currentRecord, err := w.getDnsRecord(request.Domain, request.Type, request.Name)
 
+	// Without a value, there is nothing to create, and the record is allowed to be absent until its value is set
+	if errors.Is(err, common.ErrDnsRecordNotFound) && request.CreateIfMissing != nil &&
+		request.CreateIfMissing.Value == "" {
+		w.completeDnsRecordRequestWithData(
+			resultCh,
+			data.DnsRecordData{
+				Name:           request.Name,
+				Type:           request.Type,
+				Absent:         true,
+				LastUpdateTime: time.Now(),
+			},
+			fmt.Sprintf("DNS record %s %s %s is absent, waiting for a value",
+				request.Domain, request.Type, request.Name),
+			"DNS record retrieval")
+		return
+	}
+
+	if errors.Is(err, common.ErrDnsRecordNotFound) && request.CreateIfMissing != nil {
+		currentRecord, err = w.createDnsRecord(request.Domain, request.Type, request.Name, request.CreateIfMissing)
+
+		if err != nil {
+			w.completeDnsRecordRequestWithError(
+				resultCh,
+				fmt.Errorf("error creating missing DNS record: %w", err),
+				"DNS record creation failed")
+			return
+		}
+
+		w.completeDnsRecordRequestWithCreated(resultCh, &currentRecord, "DNS record creation")
+		return
+	}
+
 	if err != nil {
-		completeDnsRecordRequestWithError(
+		w.completeDnsRecordRequestWithError(
 			resultCh,
 			fmt.Errorf("error to retrieving DNS record: %w", err),
 			"DNS record retrieval failed")
@@ -111,7 +188,7 @@ func (w *Worker) processGetDnsRecordRequest(
 
 	now := time.Now()
 
-	completeDnsRecordRequestWithSuccess(
+	w.completeDnsRecordRequestWithSuccess(
 		resultCh,
 		&currentRecord,
 		&now,
@@ -126,11 +203,40 @@ func (w *Worker) processUpdateDnsRecordRequest(
 	var currentRecord ResponseDnsRecordMessage
 	var err error
 	var updateTime time.Time
-	if request.CurrentData.LastUpdateTime.Before(time.Now().Add(-5 * time.Minute)) {
+	// The record id is required for the edit call, so the record must be retrieved if we don't have it yet.
+	if request.CurrentData.Id == "" || request.CurrentData.LastUpdateTime.Before(time.Now().Add(-5*time.Minute)) {
 		currentRecord, err = w.getDnsRecord(request.Domain, request.CurrentData.Type, request.CurrentData.Name)
 
+		if errors.Is(err, common.ErrDnsRecordNotFound) && request.CreateIfMissing != nil {
+			createSpec := *request.CreateIfMissing
+			createSpec.Value = request.Desired.Value
+
+			if !request.ValueOnly {
+				if request.Desired.Ttl != 0 {
+					createSpec.Ttl = request.Desired.Ttl
+				}
+
+				createSpec.Priority = request.Desired.Priority
+				createSpec.Notes = request.Desired.Notes
+			}
+
+			currentRecord, err = w.createDnsRecord(
+				request.Domain, request.CurrentData.Type, request.CurrentData.Name, &createSpec)
+
+			if err != nil {
+				w.completeDnsRecordRequestWithError(
+					resultCh,
+					fmt.Errorf("error creating missing DNS record: %w", err),
+					"DNS record update failed")
+				return
+			}
+
+			w.completeDnsRecordRequestWithCreated(resultCh, &currentRecord, "DNS record update")
+			return
+		}
+
 		if err != nil {
-			completeDnsRecordRequestWithError(
+			w.completeDnsRecordRequestWithError(
 				resultCh,
 				fmt.Errorf("error retrieving DNS record: %w", err),
 				"DNS record update failed")
@@ -155,22 +261,26 @@ func (w *Worker) processUpdateDnsRecordRequest(
 		updateTime = request.CurrentData.LastUpdateTime
 	}
 
-	if currentRecord.Content == request.NewValue {
-		completeDnsRecordRequestWithSuccess(
+	desiredRecord := buildDesiredRecordMessage(&currentRecord, request)
+
+	if recordMessagesMatch(&currentRecord.DnsRecordMessage, &desiredRecord) {
+		recordData := w.buildDnsRecordData(&currentRecord, &updateTime)
+		recordData.LastModifiedTime = request.CurrentData.LastModifiedTime
+		w.completeDnsRecordRequestWithUnchanged(
 			resultCh,
-			&currentRecord,
-			&updateTime,
-			&request.CurrentData.LastModifiedTime,
-			fmt.Sprintf("DNS record %s %s %s already has value \"%s\", no update needed",
-				request.Domain, request.CurrentData.Type, request.CurrentData.Name, request.NewValue),
+			recordData,
+			fmt.Sprintf("DNS record %s %s %s already has value \"%s\", TTL %s, priority %s and notes \"%s\", "+
+				"no update needed",
+				request.Domain, request.CurrentData.Type, request.CurrentData.Name, desiredRecord.Content,
+				desiredRecord.Ttl, desiredRecord.Prio, desiredRecord.Notes),
 			"DNS record update")
 		return
 	}
 
-	currentRecord, err = w.updateDnsRecord(&currentRecord, request)
+	currentRecord, err = w.updateDnsRecord(&currentRecord, request.Domain, &desiredRecord)
 
 	if err != nil {
-		completeDnsRecordRequestWithError(
+		w.completeDnsRecordRequestWithError(
 			resultCh,
 			fmt.Errorf("error updating DNS record: %w", err),
 			"DNS record update failed")
@@ -178,7 +288,7 @@ func (w *Worker) processUpdateDnsRecordRequest(
 	}
 
 	now := time.Now()
-	completeDnsRecordRequestWithSuccess(
+	w.completeDnsRecordRequestWithSuccess(
 		resultCh,
 		&currentRecord,
 		&updateTime,
@@ -187,72 +297,278 @@ func (w *Worker) processUpdateDnsRecordRequest(
 		"DNS record update")
 }
 
-func (w *Worker) getDnsRecord(domain string, recordType string, name string) (ResponseDnsRecordMessage, error) {
-	uri := fmt.Sprintf("https://api.porkbun.com/api/json/v3/dns/retrieveByNameType/%s/%s/%s",
-		domain, recordType, name)
-	requestMessage := CredsMessage{
-		ApiKey:       w.ApiKey,
-		SecretApiKey: w.ApiSecret,
+func (w *Worker) processDeleteDnsRecordRequest(
+	request *common.DeleteDnsRecordRequest,
+	resultCh chan common.DnsRecordResult) {
+	if request.Id != "" {
+		endpoint := fmt.Sprintf("dns/delete/%s/%s", request.Domain, request.Id)
+		err := w.executeHttpPost(endpoint, &CredsMessage{}, &StatusMessage{})
+		var apiErr *common.APIError
+
+		// The stored id is stale if the record was deleted or recreated outside the plugin, so fall back to deleting
+		// by name and type
+		if errors.As(err, &apiErr) && apiErr.Kind() == common.APIErrorKindNotFound {
+			w.requestLogger.Info("DNS record id not found, deleting by name and type", "record_id", request.Id)
+		} else {
+			w.completeDnsRecordDeleteRequest(request, resultCh, err)
+			return
+		}
 	}
+
+	// Check for existence first, so that deleting an absent record is not treated as an error
+	_, err := w.getDnsRecord(request.Domain, request.Type, request.Name)
+
+	if errors.Is(err, common.ErrDnsRecordNotFound) {
+		w.completeDnsRecordRequestWithData(
+			resultCh,
+			buildAbsentDnsRecordData(request, time.Now()),
+			fmt.Sprintf("DNS record %s %s %s is already absent, no delete needed",
+				request.Domain, request.Type, request.Name),
+			"DNS record delete")
+		return
+	}
+
+	if err != nil {
+		w.completeDnsRecordRequestWithError(
+			resultCh,
+			fmt.Errorf("error retrieving DNS record: %w", err),
+			"DNS record delete failed")
+		return
+	}
+
+	endpoint := fmt.Sprintf("dns/deleteByNameType/%s/%s/%s", request.Domain, request.Type, request.Name)
+	err = w.executeHttpPost(endpoint, &CredsMessage{}, &StatusMessage{})
+	w.completeDnsRecordDeleteRequest(request, resultCh, err)
+}
+
+func (w *Worker) completeDnsRecordDeleteRequest(
+	request *common.DeleteDnsRecordRequest,
+	resultCh chan common.DnsRecordResult,
+	err error) {
+	if err != nil {
+		w.completeDnsRecordRequestWithError(
+			resultCh,
+			fmt.Errorf("error deleting %s %s %s DNS record: %w", request.Domain, request.Type, request.Name, err),
+			"DNS record delete failed")
+		return
+	}
+
+	now := time.Now()
+	recordData := buildAbsentDnsRecordData(request, now)
+	recordData.LastModifiedTime = now
+	w.completeDnsRecordRequestWithData(resultCh, recordData, "Deleted successfully", "DNS record delete")
+}
+
+func (w *Worker) processRetrieveDomainRequest(
+	request *common.RetrieveDomainRequest,
+	resultCh chan common.DnsRecordResult) {
+	endpoint := fmt.Sprintf("dns/retrieve/%s", request.Domain)
+	requestMessage := CredsMessage{}
 	responseMessage := RetrieveDnsRerecordResponseMessage{}
-	err := w.executeHttpPost(uri, &requestMessage, &responseMessage)
+	err := w.executeHttpPost(endpoint, &requestMessage, &responseMessage)
 
 	if err != nil {
-		return ResponseDnsRecordMessage{},
-			fmt.Errorf("error retrieving %s %s %s DNS record: %s",
-				domain, recordType, name, err)
+		w.completeDnsRecordRequestWithError(
+			resultCh,
+			fmt.Errorf("error retrieving %s DNS records: %w", request.Domain, err),
+			"Domain retrieval failed")
+		return
+	}
+
+	now := time.Now()
+	domainRecords := make([]data.DnsRecordData, len(responseMessage.Records))
+
+	for i := range responseMessage.Records {
+		record := &responseMessage.Records[i]
+		record.Name = trimDomain(record.Name, request.Domain)
+		domainRecords[i] = w.buildDnsRecordData(record, &now)
+	}
+
+	message := fmt.Sprintf("Retrieved %d DNS records for %s", len(domainRecords), request.Domain)
+
+	if resultCh == nil {
+		w.requestLogger.Info("Domain retrieval: " + message)
+	} else {
+		resultCh <- common.DnsRecordResult{
+			Message:       message,
+			DomainRecords: domainRecords,
+		}
+		close(resultCh)
+	}
+}
+
+func (w *Worker) processPingRequest(request *common.PingRequest, resultCh chan common.DnsRecordResult) {
+	requestMessage := CredsMessage{}
+	responseMessage := PingResponseMessage{}
+	err := w.executeHttpPostUri(request.Url, "ping", &requestMessage, &responseMessage)
+
+	if err != nil {
+		w.completeDnsRecordRequestWithError(resultCh, fmt.Errorf("error calling ping: %w", err), "Ping failed")
+		return
 	}
 
-	fmt.Printf("%T Retrieved DNS record: %+v\n", w, responseMessage)
+	message := fmt.Sprintf("Ping reported address %s", responseMessage.YourIp)
+
+	if resultCh == nil {
+		w.requestLogger.Info("Ping: " + message)
+	} else {
+		resultCh <- common.DnsRecordResult{
+			Message:  message,
+			PublicIp: responseMessage.YourIp,
+		}
+		close(resultCh)
+	}
+}
+
+// trimDomain converts a fully qualified record name, as returned by the API, to a name relative to the domain.
+// The name of a record at the domain apex is returned as an empty string.
+func trimDomain(name string, domain string) string {
+	if name == domain {
+		return ""
+	}
+
+	return strings.TrimSuffix(name, "."+domain)
+}
+
+func (w *Worker) getDnsRecord(domain string, recordType string, name string) (ResponseDnsRecordMessage, error) {
+	endpoint := fmt.Sprintf("dns/retrieveByNameType/%s/%s/%s",
+		domain, recordType, name)
+	requestMessage := CredsMessage{}
+	responseMessage := RetrieveDnsRerecordResponseMessage{}
+	err := w.executeHttpPost(endpoint, &requestMessage, &responseMessage)
 
-	if responseMessage.Status != "SUCCESS" {
+	if err != nil {
 		return ResponseDnsRecordMessage{},
-			fmt.Errorf("retrieval unsuccessful: %w", err)
+			fmt.Errorf("error retrieving %s %s %s DNS record: %w",
+				domain, recordType, name, err)
 	}
 
 	recordCount := len(responseMessage.Records)
+	w.requestLogger.Debug("Retrieved DNS record", "record_count", recordCount)
+
 	if recordCount == 0 {
 		return ResponseDnsRecordMessage{},
-			fmt.Errorf("no DNS records found for %s %s %s",
-				domain, recordType, name)
+			fmt.Errorf("%w for %s %s %s",
+				common.ErrDnsRecordNotFound, domain, recordType, name)
 	} else if recordCount > 1 {
-		fmt.Printf("%T Warning: multiple DNS records found for %s %s %s, using first one\n",
-			w, domain, recordType, name)
+		w.requestLogger.Warn("Multiple DNS records found, using the first one",
+			"record_count", recordCount, "record_id", responseMessage.Records[0].Id)
 	}
 
 	currentRecord := responseMessage.Records[0]
-	domainSuffix := "." + domain
+	currentRecord.Name = trimDomain(currentRecord.Name, domain)
+
+	return currentRecord, nil
+}
 
-	if strings.HasSuffix(currentRecord.Name, domainSuffix) {
-		currentRecord.Name = strings.TrimSuffix(currentRecord.Name, domainSuffix)
+func (w *Worker) createDnsRecord(
+	domain string,
+	recordType string,
+	name string,
+	spec *data.DnsRecordSpec) (ResponseDnsRecordMessage, error) {
+	recordMessage := DnsRecordMessage{
+		Name:    name,
+		Type:    recordType,
+		Content: spec.Value,
+		Notes:   spec.Notes,
 	}
 
-	return currentRecord, nil
+	// Let the API apply its defaults when TTL or priority are not specified
+	if spec.Ttl != 0 {
+		recordMessage.Ttl = strconv.Itoa(int(spec.Ttl))
+	}
+
+	if spec.Priority != 0 {
+		recordMessage.Prio = strconv.Itoa(int(spec.Priority))
+	}
+
+	createRequestMessage := CreateDnsRecordRequestMessage{
+		DnsRecordMessage: recordMessage,
+	}
+
+	endpoint := fmt.Sprintf("dns/create/%s", domain)
+	responseMessage := CreateDnsRecordResponseMessage{}
+	err := w.executeHttpPost(endpoint, &createRequestMessage, &responseMessage)
+
+	if err != nil {
+		return ResponseDnsRecordMessage{},
+			fmt.Errorf("error sending create %s %s %s DNS record request: %w", domain, recordType, name, err)
+	}
+
+	w.requestLogger.Info("Created DNS record", "record_id", responseMessage.Id.String())
+
+	if recordMessage.Ttl == "" {
+		// The default TTL applied by the API
+		recordMessage.Ttl = "600"
+	}
+
+	if recordMessage.Prio == "" {
+		recordMessage.Prio = "0"
+	}
+
+	return ResponseDnsRecordMessage{
+		RecordWithIdMessage: RecordWithIdMessage{Id: responseMessage.Id.String()},
+		DnsRecordMessage:    recordMessage,
+	}, nil
+}
+
+// buildDesiredRecordMessage combines the current record with the desired state of the update request.
+func buildDesiredRecordMessage(
+	currentRecord *ResponseDnsRecordMessage,
+	request *common.UpdateDnsRecordRequest) DnsRecordMessage {
+	desiredRecord := DnsRecordMessage{
+		Type:  currentRecord.Type,
+		Ttl:   currentRecord.Ttl,
+		Notes: currentRecord.Notes,
+		Prio:  currentRecord.Prio,
+		// The Name in the get record response includes the domain,
+		// so we can't use it directly
+		Name:    request.CurrentData.Name,
+		Content: request.Desired.Value,
+	}
+
+	if !request.ValueOnly {
+		if request.Desired.Ttl != 0 {
+			desiredRecord.Ttl = strconv.Itoa(int(request.Desired.Ttl))
+		}
+
+		desiredRecord.Prio = strconv.Itoa(int(request.Desired.Priority))
+		desiredRecord.Notes = request.Desired.Notes
+	}
+
+	return desiredRecord
+}
+
+// recordMessagesMatch compares the fields that can be updated.  TTL and priority are compared numerically, since the
+// API may return them in a different format, or omit them.
+func recordMessagesMatch(a *DnsRecordMessage, b *DnsRecordMessage) bool {
+	return a.Content == b.Content &&
+		a.Notes == b.Notes &&
+		atoiOrZero(a.Ttl) == atoiOrZero(b.Ttl) &&
+		atoiOrZero(a.Prio) == atoiOrZero(b.Prio)
+}
+
+func atoiOrZero(value string) int {
+	result, err := strconv.Atoi(value)
+
+	if err != nil {
+		return 0
+	}
+
+	return result
 }
 
 func (w *Worker) updateDnsRecord(
 	currentRecord *ResponseDnsRecordMessage,
-	request *common.UpdateDnsRecordRequest) (ResponseDnsRecordMessage, error) {
+	domain string,
+	desiredRecord *DnsRecordMessage) (ResponseDnsRecordMessage, error) {
 	updateRequestMessage := EditDnsRecordRequestMessage{
-		CredsMessage: CredsMessage{
-			ApiKey:       w.ApiKey,
-			SecretApiKey: w.ApiSecret,
-		},
-		DnsRecordMessage: DnsRecordMessage{
-			Type:  currentRecord.Type,
-			Ttl:   currentRecord.Ttl,
-			Notes: currentRecord.Notes,
-			Prio:  currentRecord.Prio,
-			// The Name in the get record response includes the domain,
-			// so we can't use it directly
-			Name:    request.CurrentData.Name,
-			Content: request.NewValue,
-		},
-	}
-
-	uri := fmt.Sprintf("https://api.porkbun.com/api/json/v3/dns/edit/%s/%s", request.Domain, currentRecord.Id)
+		DnsRecordMessage: *desiredRecord,
+	}
+
+	endpoint := fmt.Sprintf("dns/edit/%s/%s", domain, currentRecord.Id)
 	responseMessage := StatusMessage{}
-	err := w.executeHttpPost(uri, &updateRequestMessage, &responseMessage)
+	err := w.executeHttpPost(endpoint, &updateRequestMessage, &responseMessage)
 
 	if err != nil {
 		return ResponseDnsRecordMessage{},
@@ -262,24 +578,56 @@ func (w *Worker) updateDnsRecord(
 
 	// Copy the current record and update with changed values
 	updatedRecord := *currentRecord
-	updatedRecord.Content = request.NewValue
+	updatedRecord.Content = desiredRecord.Content
+	updatedRecord.Ttl = desiredRecord.Ttl
+	updatedRecord.Prio = desiredRecord.Prio
+	updatedRecord.Notes = desiredRecord.Notes
 
 	return updatedRecord, nil
 }
 
-func (w *Worker) executeHttpPost(uri string, body any, result any) error {
+// executeHttpPost posts the body to the passed endpoint, relative to the API base URL, and unmarshals the response into
+// result.  Returns a *common.APIError if the API responds with an error HTTP status code or a status other than
+// SUCCESS.
+func (w *Worker) executeHttpPost(endpoint string, body credentialedMessage, result apiResponse) error {
+	return w.executeHttpPostUri(w.apiBaseUrl+"/"+endpoint, endpoint, body, result)
+}
+
+// executeHttpPostUri is executeHttpPost for endpoints outside the API base URL.  The endpoint identifies the call in
+// errors and metrics.
+func (w *Worker) executeHttpPostUri(uri string, endpoint string, body credentialedMessage, result apiResponse) error {
+	if w.rateLimiter != nil {
+		if err := w.rateLimiter.Wait(w.requestCtx); err != nil {
+			return fmt.Errorf("gave up waiting for the rate limiter: %w", err)
+		}
+	}
+
+	// The credentials are looked up for every call, so rotated credentials are used from the next call on
+	apiCredentials, err := w.credentials.Credentials()
+
+	if err != nil {
+		return fmt.Errorf("unable to get API credentials: %w", err)
+	}
+
+	body.setCredentials(apiCredentials)
 	jsonBytes, err := json.Marshal(body)
 
 	if err != nil {
 		return fmt.Errorf("error serializing request body: %w", err)
 	}
 
+	// The time spent waiting for the rate limiter isn't part of the latency
+	startTime := time.Now()
+	statusCode := 0
+	success := false
+	defer func() { w.observeApiCall(endpoint, statusCode, time.Since(startTime), success) }()
 	response, err := w.httpClient.Post(uri, "application/json", bytes.NewReader(jsonBytes))
 
 	if err != nil {
 		return fmt.Errorf("http post failed: %w", err)
 	}
-	defer func() { closeResponse(response) }()
+	defer func() { w.closeResponse(response) }()
+	statusCode = response.StatusCode
 
 	responseBytes, err := io.ReadAll(response.Body)
 
@@ -288,35 +636,75 @@ func (w *Worker) executeHttpPost(uri string, body any, result any) error {
 	}
 
 	err = json.Unmarshal(responseBytes, result)
+	httpSuccess := response.StatusCode >= 200 && response.StatusCode < 300
 
 	if err != nil {
+		if !httpSuccess {
+			return w.checkRateLimited(&common.APIError{
+				Message:        string(responseBytes),
+				HttpStatusCode: response.StatusCode,
+				Endpoint:       endpoint,
+				RetryAfter:     parseRetryAfter(response.Header, time.Now(), w.requestLogger),
+			})
+		}
+
 		return fmt.Errorf("error unmarshalling response: %w (body: %s)", err, string(responseBytes))
 	}
 
+	status := result.statusMessage()
+
+	if !httpSuccess || status.Status != "SUCCESS" {
+		return w.checkRateLimited(&common.APIError{
+			Status:         status.Status,
+			Message:        status.Message,
+			HttpStatusCode: response.StatusCode,
+			Endpoint:       endpoint,
+			RetryAfter:     parseRetryAfter(response.Header, time.Now(), w.requestLogger),
+		})
+	}
+
+	success = true
+
 	return nil
 }
 
-func closeResponse(response *http.Response) {
+func (w *Worker) observeApiCall(endpoint string, statusCode int, latency time.Duration, success bool) {
+	if w.apiMetrics != nil {
+		w.apiMetrics.observe(endpoint, statusCode, latency, success)
+	}
+}
+
+// checkRateLimited pauses the rate limiter, shared by all workers, when the error indicates that the API rate limit
+// was exceeded.
+func (w *Worker) checkRateLimited(apiErr *common.APIError) *common.APIError {
+	if w.rateLimiter != nil && apiErr.IsRateLimited() {
+		w.rateLimiter.RateLimited(apiErr.RetryAfter)
+	}
+
+	return apiErr
+}
+
+func (w *Worker) closeResponse(response *http.Response) {
 	if response != nil {
 		closeErr := response.Body.Close()
 
 		if closeErr != nil {
-			fmt.Printf("Error closing response body: %s\n", closeErr)
+			w.requestLogger.Warn("Error closing response body", "error", closeErr)
 		}
 	}
 }
 
-func buildDnsRecordData(record *ResponseDnsRecordMessage, lastUpdateTime *time.Time) data.DnsRecordData {
+func (w *Worker) buildDnsRecordData(record *ResponseDnsRecordMessage, lastUpdateTime *time.Time) data.DnsRecordData {
 	ttlInt, err := strconv.Atoi(record.Ttl)
 
 	if err != nil {
-		fmt.Printf("Error parsing TTL from \"%s\": %s\n", record.Ttl, err)
+		w.requestLogger.Warn("Error parsing TTL", "ttl", record.Ttl, "record_id", record.Id, "error", err)
 	}
 
 	priorityInt, err := strconv.Atoi(record.Prio)
 
 	if err != nil {
-		fmt.Printf("Error parsing priority from \"%s\": %s\n", record.Ttl, err)
+		w.requestLogger.Warn("Error parsing priority", "priority", record.Prio, "record_id", record.Id, "error", err)
 	}
 
 	return data.DnsRecordData{
@@ -331,22 +719,76 @@ func buildDnsRecordData(record *ResponseDnsRecordMessage, lastUpdateTime *time.T
 	}
 }
 
-func completeDnsRecordRequestWithSuccess(
+func buildAbsentDnsRecordData(request *common.DeleteDnsRecordRequest, lastUpdateTime time.Time) data.DnsRecordData {
+	return data.DnsRecordData{
+		Name:           request.Name,
+		Type:           request.Type,
+		Absent:         true,
+		LastUpdateTime: lastUpdateTime,
+	}
+}
+
+func (w *Worker) completeDnsRecordRequestWithSuccess(
 	resultCh chan common.DnsRecordResult,
 	record *ResponseDnsRecordMessage,
 	lastUpdateTime *time.Time,
 	lastModifiedTime *time.Time,
 	message string,
 	logMessage string) {
+	recordData := w.buildDnsRecordData(record, lastUpdateTime)
+
+	if lastModifiedTime != nil {
+		recordData.LastModifiedTime = *lastModifiedTime
+	}
+
+	w.completeDnsRecordRequestWithData(resultCh, recordData, message, logMessage)
+}
+
+func (w *Worker) completeDnsRecordRequestWithCreated(
+	resultCh chan common.DnsRecordResult,
+	record *ResponseDnsRecordMessage,
+	logMessage string) {
+	now := time.Now()
+	recordData := w.buildDnsRecordData(record, &now)
+	recordData.LastModifiedTime = now
+
 	if resultCh == nil {
-		fmt.Printf("%s: %s\n", logMessage, message)
+		w.requestLogger.Info(logMessage + ": Created successfully")
 	} else {
-		recordData := buildDnsRecordData(record, lastUpdateTime)
+		resultCh <- common.DnsRecordResult{
+			Message:     "Created successfully",
+			CurrentData: recordData,
+			Created:     true,
+		}
+		close(resultCh)
+	}
+}
 
-		if lastModifiedTime != nil {
-			recordData.LastModifiedTime = *lastModifiedTime
+func (w *Worker) completeDnsRecordRequestWithUnchanged(
+	resultCh chan common.DnsRecordResult,
+	recordData data.DnsRecordData,
+	message string,
+	logMessage string) {
+	if resultCh == nil {
+		w.requestLogger.Info(logMessage + ": " + message)
+	} else {
+		resultCh <- common.DnsRecordResult{
+			Message:     message,
+			CurrentData: recordData,
+			Unchanged:   true,
 		}
+		close(resultCh)
+	}
+}
 
+func (w *Worker) completeDnsRecordRequestWithData(
+	resultCh chan common.DnsRecordResult,
+	recordData data.DnsRecordData,
+	message string,
+	logMessage string) {
+	if resultCh == nil {
+		w.requestLogger.Info(logMessage + ": " + message)
+	} else {
 		resultCh <- common.DnsRecordResult{
 			Message:     message,
 			CurrentData: recordData,
@@ -355,9 +797,12 @@ func completeDnsRecordRequestWithSuccess(
 	}
 }
 
-func completeDnsRecordRequestWithError(resultCh chan common.DnsRecordResult, err error, logMessage string) {
+func (w *Worker) completeDnsRecordRequestWithError(
+	resultCh chan common.DnsRecordResult,
+	err error,
+	logMessage string) {
 	if resultCh == nil {
-		fmt.Printf("%s: %s\n", logMessage, err)
+		w.requestLogger.Error(logMessage, "error", err)
 	} else {
 		resultCh <- common.DnsRecordResult{
 			Error: err,
@@ -0,0 +1,404 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/credentials"
+	"github.com/avanha/pmaas-plugin-porkbun/data"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+	"github.com/avanha/pmaas-plugin-porkbun/porkbuntest"
+)
+
+const testDomain = "example.com"
+
+func newTestWorker(t *testing.T) (*Worker, *porkbuntest.Server) {
+	t.Helper()
+	server := porkbuntest.NewServer("apiKey", "apiSecret")
+	t.Cleanup(server.Close)
+	server.AddDomain(testDomain)
+	w := NewPorkBunWorker(
+		credentials.NewStaticProvider("apiKey", "apiSecret"),
+		server.ApiBaseUrl(),
+		server.Client(),
+		make(chan common.Request),
+		nil,
+		NewApiMetrics(),
+		slog.New(slog.NewTextHandler(io.Discard, nil)))
+	w.runCtx = context.Background()
+	w.requestCtx = w.runCtx
+
+	return w, server
+}
+
+// process runs the request on the calling goroutine and returns its result.
+func process(w *Worker, request common.Request) common.DnsRecordResult {
+	request.ResultCh = make(chan common.DnsRecordResult, 1)
+	w.processRequest(&request)
+
+	return <-request.ResultCh
+}
+
+func getRequest(recordType string, name string) common.Request {
+	return common.Request{
+		RequestType:         common.RequestTypeGetDnsRecord,
+		GetDnsRecordRequest: common.GetDnsRecordRequest{Domain: testDomain, Type: recordType, Name: name},
+	}
+}
+
+func TestRetrieveDnsRecord(t *testing.T) {
+	w, server := newTestWorker(t)
+	id := server.AddRecord(testDomain, porkbuntest.Record{
+		Type: "A", Name: "www", Content: "192.0.2.1", Ttl: "300", Notes: "web"})
+
+	result := process(w, getRequest("A", "www"))
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	expected := data.DnsRecordData{Id: id, Name: "www", Type: "A", Value: "192.0.2.1", Ttl: 300, Notes: "web"}
+	result.CurrentData.LastUpdateTime = time.Time{}
+
+	if result.CurrentData != expected {
+		t.Errorf("got %+v, want %+v", result.CurrentData, expected)
+	}
+}
+
+func TestRetrieveApexDnsRecord(t *testing.T) {
+	w, server := newTestWorker(t)
+	server.AddRecord(testDomain, porkbuntest.Record{Type: "A", Content: "192.0.2.1"})
+
+	result := process(w, getRequest("A", ""))
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	if result.CurrentData.Name != "" {
+		t.Errorf("got name %q, want an empty name for the apex", result.CurrentData.Name)
+	}
+}
+
+func TestRetrieveMissingDnsRecord(t *testing.T) {
+	w, _ := newTestWorker(t)
+
+	result := process(w, getRequest("A", "www"))
+
+	if !errors.Is(result.Error, common.ErrDnsRecordNotFound) {
+		t.Errorf("got error %v, want %v", result.Error, common.ErrDnsRecordNotFound)
+	}
+}
+
+func TestRetrieveDomain(t *testing.T) {
+	w, server := newTestWorker(t)
+	server.AddRecord(testDomain, porkbuntest.Record{Type: "A", Content: "192.0.2.1"})
+	server.AddRecord(testDomain, porkbuntest.Record{Type: "MX", Name: "mail", Content: "mx.example.net", Prio: "10"})
+
+	result := process(w, common.Request{
+		RequestType:           common.RequestTypeRetrieveDomain,
+		RetrieveDomainRequest: common.RetrieveDomainRequest{Domain: testDomain},
+	})
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	if len(result.DomainRecords) != 2 {
+		t.Fatalf("got %d records, want 2", len(result.DomainRecords))
+	}
+
+	if result.DomainRecords[0].Name != "" || result.DomainRecords[1].Name != "mail" {
+		t.Errorf("got names %q and %q, want names relative to the domain",
+			result.DomainRecords[0].Name, result.DomainRecords[1].Name)
+	}
+
+	if result.DomainRecords[1].Priority != 10 {
+		t.Errorf("got priority %d, want 10", result.DomainRecords[1].Priority)
+	}
+}
+
+func TestCreateMissingDnsRecord(t *testing.T) {
+	w, server := newTestWorker(t)
+	request := getRequest("A", "www")
+	request.GetDnsRecordRequest.CreateIfMissing = &data.DnsRecordSpec{Value: "192.0.2.1", Ttl: 300}
+
+	result := process(w, request)
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	if !result.Created || result.CurrentData.Id == "" {
+		t.Errorf("got created %t and id %q, want a created record with an id", result.Created, result.CurrentData.Id)
+	}
+
+	record, ok := server.FindRecord(testDomain, "A", "www")
+
+	if !ok || record.Content != "192.0.2.1" || record.Ttl != "300" || record.Id != result.CurrentData.Id {
+		t.Errorf("got server record %+v (found %t), want the created record", record, ok)
+	}
+}
+
+func TestUpdateDnsRecord(t *testing.T) {
+	w, server := newTestWorker(t)
+	server.AddRecord(testDomain, porkbuntest.Record{Type: "A", Name: "www", Content: "192.0.2.1", Ttl: "300"})
+
+	result := process(w, common.Request{
+		RequestType: common.RequestTypeUpdateDnsRecord,
+		UpdateDnsRecordRequest: common.UpdateDnsRecordRequest{
+			Domain:      testDomain,
+			CurrentData: data.DnsRecordData{Type: "A", Name: "www"},
+			Desired:     data.DnsRecordSpec{Value: "192.0.2.2"},
+			ValueOnly:   true,
+		},
+	})
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	if result.Unchanged || result.CurrentData.Value != "192.0.2.2" || result.CurrentData.Ttl != 300 {
+		t.Errorf("got %+v, want the updated value with the TTL kept", result.CurrentData)
+	}
+
+	record, _ := server.FindRecord(testDomain, "A", "www")
+
+	if record.Content != "192.0.2.2" || record.Ttl != "300" {
+		t.Errorf("got server record %+v, want the updated value with the TTL kept", record)
+	}
+
+	if count := server.RequestCount("dns/edit"); count != 1 {
+		t.Errorf("got %d edit requests, want 1", count)
+	}
+}
+
+func TestUpdateDnsRecordClearsNotes(t *testing.T) {
+	w, server := newTestWorker(t)
+	server.AddRecord(testDomain, porkbuntest.Record{Type: "A", Name: "www", Content: "192.0.2.1", Notes: "old"})
+
+	result := process(w, common.Request{
+		RequestType: common.RequestTypeUpdateDnsRecord,
+		UpdateDnsRecordRequest: common.UpdateDnsRecordRequest{
+			Domain:      testDomain,
+			CurrentData: data.DnsRecordData{Type: "A", Name: "www"},
+			Desired:     data.DnsRecordSpec{Value: "192.0.2.1"},
+		},
+	})
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	record, _ := server.FindRecord(testDomain, "A", "www")
+
+	if result.CurrentData.Notes != "" || record.Notes != "" {
+		t.Errorf("got notes %q and server notes %q, want both cleared", result.CurrentData.Notes, record.Notes)
+	}
+}
+
+func TestUpdateUnchangedDnsRecord(t *testing.T) {
+	w, server := newTestWorker(t)
+	server.AddRecord(testDomain, porkbuntest.Record{Type: "A", Name: "www", Content: "192.0.2.1"})
+
+	result := process(w, common.Request{
+		RequestType: common.RequestTypeUpdateDnsRecord,
+		UpdateDnsRecordRequest: common.UpdateDnsRecordRequest{
+			Domain:      testDomain,
+			CurrentData: data.DnsRecordData{Type: "A", Name: "www"},
+			Desired:     data.DnsRecordSpec{Value: "192.0.2.1"},
+			ValueOnly:   true,
+		},
+	})
+
+	if result.Error != nil || !result.Unchanged {
+		t.Errorf("got error %v and unchanged %t, want an unchanged result", result.Error, result.Unchanged)
+	}
+
+	if count := server.RequestCount("dns/edit"); count != 0 {
+		t.Errorf("got %d edit requests, want 0", count)
+	}
+}
+
+func TestDeleteDnsRecordByNameAndType(t *testing.T) {
+	w, server := newTestWorker(t)
+	server.AddRecord(testDomain, porkbuntest.Record{Type: "A", Name: "www", Content: "192.0.2.1"})
+	server.AddRecord(testDomain, porkbuntest.Record{Type: "A", Name: "www", Content: "192.0.2.2"})
+
+	result := process(w, common.Request{
+		RequestType:            common.RequestTypeDeleteDnsRecord,
+		DeleteDnsRecordRequest: common.DeleteDnsRecordRequest{Domain: testDomain, Type: "A", Name: "www"},
+	})
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	if !result.CurrentData.Absent {
+		t.Errorf("got %+v, want an absent record", result.CurrentData)
+	}
+
+	if records := server.Records(testDomain); len(records) != 0 {
+		t.Errorf("got server records %+v, want none", records)
+	}
+
+	if count := server.RequestCount("dns/deleteByNameType"); count != 1 {
+		t.Errorf("got %d delete by name and type requests, want 1", count)
+	}
+}
+
+func TestDeleteDnsRecordById(t *testing.T) {
+	w, server := newTestWorker(t)
+	server.AddRecord(testDomain, porkbuntest.Record{Type: "TXT", Content: "first"})
+	id := server.AddRecord(testDomain, porkbuntest.Record{Type: "TXT", Content: "second"})
+
+	result := process(w, common.Request{
+		RequestType:            common.RequestTypeDeleteDnsRecord,
+		DeleteDnsRecordRequest: common.DeleteDnsRecordRequest{Domain: testDomain, Type: "TXT", Id: id},
+	})
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	records := server.Records(testDomain)
+
+	if len(records) != 1 || records[0].Content != "first" {
+		t.Errorf("got server records %+v, want only the first record", records)
+	}
+
+	if count := server.RequestCount("dns/retrieveByNameType"); count != 0 {
+		t.Errorf("got %d retrieve requests, want none when deleting by id", count)
+	}
+}
+
+func TestDeleteDnsRecordByStaleId(t *testing.T) {
+	w, server := newTestWorker(t)
+	server.AddRecord(testDomain, porkbuntest.Record{Type: "A", Name: "www", Content: "192.0.2.1"})
+
+	result := process(w, common.Request{
+		RequestType: common.RequestTypeDeleteDnsRecord,
+		DeleteDnsRecordRequest: common.DeleteDnsRecordRequest{
+			Domain: testDomain, Type: "A", Name: "www", Id: "1"},
+	})
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error: %v", result.Error)
+	}
+
+	if records := server.Records(testDomain); len(records) != 0 {
+		t.Errorf("got server records %+v, want none", records)
+	}
+}
+
+func TestDeleteAbsentDnsRecord(t *testing.T) {
+	w, server := newTestWorker(t)
+
+	result := process(w, common.Request{
+		RequestType:            common.RequestTypeDeleteDnsRecord,
+		DeleteDnsRecordRequest: common.DeleteDnsRecordRequest{Domain: testDomain, Type: "A", Name: "www"},
+	})
+
+	if result.Error != nil || !result.CurrentData.Absent {
+		t.Errorf("got error %v and %+v, want an absent record", result.Error, result.CurrentData)
+	}
+
+	if count := server.RequestCount("dns/delete"); count != 0 {
+		t.Errorf("got %d delete requests, want 0", count)
+	}
+}
+
+func TestApiErrorClassification(t *testing.T) {
+	tests := []struct {
+		name       string
+		fault      porkbuntest.Fault
+		status     string
+		httpStatus int
+		kind       common.APIErrorKind
+		retryAfter time.Duration
+	}{
+		{
+			name:       "status ERROR",
+			fault:      porkbuntest.Fault{ErrorMessage: "Invalid domain."},
+			status:     "ERROR",
+			httpStatus: http.StatusBadRequest,
+			kind:       common.APIErrorKindInvalidDomain,
+		},
+		{
+			name:       "status ERROR with HTTP 200",
+			fault:      porkbuntest.Fault{Body: `{"status":"ERROR","message":"Invalid API key. (002)"}`},
+			status:     "ERROR",
+			httpStatus: http.StatusOK,
+			kind:       common.APIErrorKindAuth,
+		},
+		{
+			name:       "HTTP 5xx",
+			fault:      porkbuntest.Fault{HttpStatus: http.StatusServiceUnavailable, Body: "unavailable"},
+			httpStatus: http.StatusServiceUnavailable,
+			kind:       common.APIErrorKindServer,
+		},
+		{
+			name: "HTTP 429",
+			fault: porkbuntest.Fault{
+				HttpStatus: http.StatusTooManyRequests,
+				Body:       `{"status":"ERROR","message":"Too many requests"}`,
+				RetryAfter: "2",
+			},
+			status:     "ERROR",
+			httpStatus: http.StatusTooManyRequests,
+			kind:       common.APIErrorKindRateLimited,
+			retryAfter: 2 * time.Second,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w, server := newTestWorker(t)
+			test.fault.Endpoint = "dns/retrieveByNameType"
+			server.AddFault(test.fault)
+
+			result := process(w, getRequest("A", "www"))
+
+			var apiErr *common.APIError
+
+			if !errors.As(result.Error, &apiErr) {
+				t.Fatalf("got error %v, want a *common.APIError", result.Error)
+			}
+
+			if apiErr.Status != test.status || apiErr.HttpStatusCode != test.httpStatus ||
+				apiErr.Kind() != test.kind || apiErr.RetryAfter != test.retryAfter {
+				t.Errorf("got status %q, HTTP %d, kind %s and retry after %s, "+
+					"want status %q, HTTP %d, kind %s and retry after %s",
+					apiErr.Status, apiErr.HttpStatusCode, apiErr.Kind(), apiErr.RetryAfter,
+					test.status, test.httpStatus, test.kind, test.retryAfter)
+			}
+
+			if apiErr.Endpoint != "dns/retrieveByNameType/example.com/A/www" {
+				t.Errorf("got endpoint %q", apiErr.Endpoint)
+			}
+		})
+	}
+}
+
+func TestPing(t *testing.T) {
+	w, server := newTestWorker(t)
+	server.SetPingIp("203.0.113.7")
+
+	result := process(w, common.Request{
+		RequestType: common.RequestTypePing,
+		PingRequest: common.PingRequest{Url: server.ApiBaseUrl() + "/ping"},
+	})
+
+	if result.Error != nil || result.PublicIp != "203.0.113.7" {
+		t.Errorf("got error %v and address %q, want 203.0.113.7", result.Error, result.PublicIp)
+	}
+
+	if stats := w.apiMetrics.Stats(); len(stats) != 1 || stats[0].Endpoint != "ping" || stats[0].CallCount != 1 {
+		t.Errorf("got API stats %+v, want one ping call", stats)
+	}
+}
@@ -2,24 +2,36 @@ package porkbun
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/avanha/pmaas-common/queue"
 	"github.com/avanha/pmaas-plugin-porkbun/config"
+	"github.com/avanha/pmaas-plugin-porkbun/credentials"
 	"github.com/avanha/pmaas-plugin-porkbun/data"
 	"github.com/avanha/pmaas-plugin-porkbun/entities"
 	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
 	"github.com/avanha/pmaas-plugin-porkbun/internal/dnsRecord"
 	"github.com/avanha/pmaas-plugin-porkbun/internal/http"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/poll"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/publicip"
 	"github.com/avanha/pmaas-plugin-porkbun/internal/worker"
+	"github.com/avanha/pmaas-plugin-porkbun/state"
 	"github.com/avanha/pmaas-spi"
 )
 
 func NewPluginConfig() config.PluginConfig {
 	return config.PluginConfig{
-		Domains: make(map[string]*config.Domain),
+		ApiBaseUrl: config.DefaultApiBaseUrl,
+		Retry:      config.DefaultRetryConfig(),
+		RateLimit:  config.DefaultRateLimitConfig(),
+		Workers:    1,
+		PublicIp:   config.DefaultPublicIpConfig(),
+		StateFile:  config.DefaultStateFile,
+		Domains:    make(map[string]*config.Domain),
 	}
 }
 
@@ -28,14 +40,24 @@ type plugin struct {
 	container            spi.IPMAASContainer
 	entityCounter        int
 	dnsRecords           map[string]*dnsRecord.DnsRecord
+	publicIpRecords      map[string]*dnsRecord.DnsRecord
+	dualStackRecords     []dualStackRecord
+	domainPollSchedules  map[string]*poll.Schedule
+	stateStore           state.Store
+	stateSaveScheduled   bool
+	publicIpDetector     *publicip.Detector
+	lastPublicIpResult   publicip.Result
 	requestCh            chan common.Request
 	requestQueue         *queue.RequestQueue[common.Request]
-	requestRetryingQueue *queue.RetryingRequestQueue[common.Request, common.DnsRecordResult]
-	worker               *worker.Worker
+	requestRetryingQueue *retryingQueue
+	workers              []*worker.Worker
+	rateLimiter          *worker.RateLimiter
+	apiMetrics           *worker.ApiMetrics
 	workersWg            sync.WaitGroup
 	httpHandler          *http.Handler
 	cancelFn             context.CancelFunc
 	running              bool
+	logger               *slog.Logger
 }
 
 type Plugin interface {
@@ -43,51 +65,65 @@ type Plugin interface {
 }
 
 func NewPlugin(config config.PluginConfig) Plugin {
+	logger := config.Logger
+
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	logger = logger.With("plugin", "porkbun")
+
 	return &plugin{
-		config:      config,
-		dnsRecords:  make(map[string]*dnsRecord.DnsRecord),
-		requestCh:   make(chan common.Request),
-		httpHandler: http.NewHandler(),
+		config:              config,
+		dnsRecords:          make(map[string]*dnsRecord.DnsRecord),
+		publicIpRecords:     make(map[string]*dnsRecord.DnsRecord),
+		domainPollSchedules: make(map[string]*poll.Schedule),
+		requestCh:           make(chan common.Request),
+		httpHandler:         http.NewHandler(logger),
+		logger:              logger,
 	}
 }
 
 func (p *plugin) Init(container spi.IPMAASContainer) {
+	// Fail on start, rather than leaving invalid records unmanaged
+	if err := p.config.Validate(); err != nil {
+		panic(fmt.Errorf("invalid porkbun plugin configuration: %w", err))
+	}
+
 	p.container = container
+	p.initStateStore()
 	p.processConfig()
 	p.httpHandler.Init(container, &entityStoreAdapter{parent: p})
 	p.requestQueue = queue.NewRequestQueue(p.requestCh)
-	p.requestRetryingQueue = queue.NewRetryingRequestQueue(
-		getResultChannel,
-		exchangeResultChannel,
-		createErrorResponse,
-		isFailedResult,
-		canRetryRequest,
-		p.requestQueue)
-	p.worker = worker.NewPorkBunWorker(p.config.ApiKey, p.config.ApiSecret, p.requestCh)
-}
+	p.requestRetryingQueue = newRetryingQueue(p.requestQueue, newRetryPolicy(p.config.Retry, p.logger).canRetryRequest)
+	apiBaseUrl := p.config.ApiBaseUrl
 
-func getResultChannel(request *common.Request) chan common.DnsRecordResult {
-	return request.ResultCh
-}
+	if apiBaseUrl == "" {
+		apiBaseUrl = config.DefaultApiBaseUrl
+	}
 
-func exchangeResultChannel(
-	request *common.Request, newChannel chan common.DnsRecordResult) chan common.DnsRecordResult {
-	currentChannel := request.ResultCh
-	request.ResultCh = newChannel
-	return currentChannel
-}
+	p.rateLimiter = worker.NewRateLimiter(
+		p.config.RateLimit.RequestsPerSecond, p.config.RateLimit.Burst, p.logger)
+	p.apiMetrics = worker.NewApiMetrics()
+	credentialsProvider := p.config.Credentials
 
-func createErrorResponse(err error) common.DnsRecordResult {
-	return common.DnsRecordResult{Error: err}
-}
+	if credentialsProvider == nil {
+		credentialsProvider = credentials.NewStaticProvider(p.config.ApiKey, p.config.ApiSecret)
+	}
 
-func isFailedResult(result *common.DnsRecordResult) bool {
-	return result.Error != nil
-}
+	p.workers = make([]*worker.Worker, max(p.config.Workers, 1))
+
+	for i := range p.workers {
+		p.workers[i] = worker.NewPorkBunWorker(
+			credentialsProvider, apiBaseUrl, p.config.HttpClient, p.requestCh, p.rateLimiter, p.apiMetrics,
+			p.logger.With("worker", i))
+	}
 
-func canRetryRequest(_ *common.Request, _ *common.DnsRecordResult,
-	attempts int, _ time.Time) bool {
-	return attempts < 11
+	if len(p.publicIpRecords) > 0 {
+		// Failed pings aren't retried, the next detection calls them again
+		p.publicIpDetector = newPublicIpDetector(&p.config, apiBaseUrl,
+			func(request common.Request) error { return p.requestQueue.Enqueue(&request) })
+	}
 }
 
 func (p *plugin) Start() {
@@ -95,21 +131,31 @@ func (p *plugin) Start() {
 	ctx, cancel := context.WithCancel(context.Background())
 	p.cancelFn = cancel
 	p.workersWg.Go(p.requestQueue.Run)
-	p.workersWg.Go(p.requestRetryingQueue.Run)
-	p.workersWg.Go(func() { p.worker.Run(ctx) })
+
+	for _, w := range p.workers {
+		p.workersWg.Go(func() { w.Run(ctx) })
+	}
+
 	go func() { p.poll(ctx) }()
+
+	if p.publicIpDetector != nil {
+		go func() { p.pollPublicIp(ctx) }()
+	}
+
 	p.running = true
+	p.startPolling()
+	p.deleteAbsentRecords()
 }
 
 func (p *plugin) Stop() chan func() {
-	fmt.Printf("%T Stopping...\n", p)
+	p.logger.Info("Stopping...")
 	p.running = false
 	p.requestRetryingQueue.Stop()
 	p.requestQueue.Stop()
 	p.cancelFn()
 	callbackCh := make(chan func())
 	go func() {
-		fmt.Printf("%T Waiting for workers to finish...\n", p)
+		p.logger.Info("Waiting for workers to finish...")
 		p.workersWg.Wait()
 		callbackCh <- func() { p.onWorkersStopped(callbackCh) }
 	}()
@@ -118,63 +164,137 @@ func (p *plugin) Stop() chan func() {
 }
 
 func (p *plugin) onWorkersStopped(callbackCh chan func()) {
-	fmt.Printf("%T Workers stopped, deregistering entities...\n", p)
+	p.logger.Info("Workers stopped, saving state and deregistering entities...")
+	p.saveState()
 	p.deregisterEntities()
 	close(callbackCh)
 }
 
 func (p *plugin) processConfig() {
+	pluginPollConfig := config.DefaultPollConfig().WithOverride(&p.config.Poll)
+	savedStates := p.loadState()
+
 	for _, configuredDomain := range p.config.Domains {
+		domainPollConfig := pluginPollConfig.WithOverride(configuredDomain.Poll)
+
+		if configuredDomain.DiscoverRecords {
+			p.domainPollSchedules[configuredDomain.Name] = poll.NewSchedule(domainPollConfig)
+		}
+
 		for _, configuredDnsRecord := range configuredDomain.DnsRecords {
-			key := fmt.Sprintf("%s_%s.%s",
-				configuredDnsRecord.Name, configuredDomain.Name, configuredDnsRecord.Type)
+			key := dnsRecordKey(configuredDomain.Name, configuredDnsRecord.Type, configuredDnsRecord.Name)
+			recordLogger := p.logger.With(
+				"domain", configuredDomain.Name, "type", configuredDnsRecord.Type, "name", configuredDnsRecord.Name)
+
+			var createSpec *data.DnsRecordSpec
+
+			if (configuredDnsRecord.EnsureExists || configuredDnsRecord.Reconcile) && configuredDnsRecord.Absent {
+				recordLogger.Warn("DNS record is configured to both exist and be absent, it will be deleted")
+			} else if configuredDnsRecord.EnsureExists || configuredDnsRecord.Reconcile {
+				createSpec = &data.DnsRecordSpec{
+					Value:    configuredDnsRecord.Value,
+					Ttl:      configuredDnsRecord.Ttl,
+					Priority: configuredDnsRecord.Priority,
+					Notes:    configuredDnsRecord.Notes,
+				}
+			}
+
 			dnsRecordInstance := dnsRecord.NewDnsRecord(
 				p.container,
 				fmt.Sprintf("DnsRecord_%v", p.nextEntityId()),
 				configuredDomain.Name,
 				configuredDnsRecord.Type,
 				configuredDnsRecord.Name,
+				dnsRecord.Options{
+					CreateSpec:     createSpec,
+					MustBeAbsent:   configuredDnsRecord.Absent,
+					Reconcile:      configuredDnsRecord.Reconcile,
+					WatchDrift:     configuredDnsRecord.WatchDrift,
+					EventListeners: configuredDnsRecord.EventListeners(),
+					Poll:           domainPollConfig.WithOverride(configuredDnsRecord.Poll),
+					OnChanged:      p.scheduleStateSave,
+					Logger:         p.logger,
+				},
 				p.enqueueRequest,
 				configuredDnsRecord.OnEntityStubAvailableListeners())
+
+			if savedState, ok := savedStates[key]; ok {
+				dnsRecordInstance.RestoreState(savedState)
+			}
 			p.dnsRecords[key] = dnsRecordInstance
+
+			if configuredDnsRecord.TrackPublicIp {
+				p.publicIpRecords[key] = dnsRecordInstance
+			}
 		}
+
+		p.addDualStackRecords(configuredDomain)
 	}
 }
 
+// dnsRecordKey returns the key of a configured record in the plugin's dnsRecords map.
+func dnsRecordKey(domain string, recordType string, name string) string {
+	return fmt.Sprintf("%s_%s.%s", name, domain, recordType)
+}
+
 func (p *plugin) registerEntities() {
 	for key, record := range p.dnsRecords {
-		// This lambda captures the plugin instance and the hostInstance
-		// and passes it to the entity manager.  However, entities are deregistered on plugin
-		// stop, so this will not leak resources, and is OK,
-		var recordStubFactoryFn spi.EntityStubFactoryFunc = func() (any, error) {
-			return record.GetStub(), nil
-		}
-		pmassEntityId, err := p.container.RegisterEntity(
-			record.Id(), entities.DnsRecordType, record.Name(), recordStubFactoryFn)
+		p.registerEntity(key, record)
+	}
+}
 
-		if err != nil {
-			fmt.Printf("Error registering %s: %v\n", key, err)
-			continue
-		}
+func (p *plugin) registerEntity(key string, record *dnsRecord.DnsRecord) {
+	// This lambda captures the plugin instance and the hostInstance
+	// and passes it to the entity manager.  However, entities are deregistered on plugin
+	// stop, so this will not leak resources, and is OK,
+	var recordStubFactoryFn spi.EntityStubFactoryFunc = func() (any, error) {
+		return record.GetStub(), nil
+	}
+	pmassEntityId, err := p.container.RegisterEntity(
+		record.Id(), entities.DnsRecordType, record.Name(), recordStubFactoryFn)
 
-		record.SetPmaasEntityId(pmassEntityId)
-		record.ProcessConfiguredListeners(p.container)
+	if err != nil {
+		p.logger.Error("Error registering DNS record", "key", key, "error", err)
+		return
 	}
+
+	record.SetPmaasEntityId(pmassEntityId)
+	record.ProcessConfiguredListeners(p.container)
 }
 
 func (p *plugin) deregisterEntities() {
 	for name, record := range p.dnsRecords {
-		if record.PmaasEntityId() != "" {
-			err := p.container.DeregisterEntity(record.PmaasEntityId())
+		p.deregisterEntity(name, record)
+	}
+}
 
-			if err == nil {
-				record.ClearPmaasEntityId()
-			} else {
-				fmt.Printf("Error deregistering DnsRecord %s: %v\n", name, err)
-			}
+func (p *plugin) deregisterEntity(name string, record *dnsRecord.DnsRecord) {
+	if record.PmaasEntityId() != "" {
+		err := p.container.DeregisterEntity(record.PmaasEntityId())
+
+		if err == nil {
+			record.ClearPmaasEntityId()
+		} else {
+			p.logger.Error("Error deregistering DNS record", "key", name, "error", err)
 		}
+	}
+
+	record.CloseStubIfPresent()
+}
+
+// deleteAbsentRecords enqueues delete requests for the records configured to be absent, so that stale records
+// are cleaned up on start, rather than on the first refresh.
+func (p *plugin) deleteAbsentRecords() {
+	for key, record := range p.dnsRecords {
+		if !record.MustBeAbsent() {
+			continue
+		}
+
+		err := record.Delete()
 
-		record.CloseStubIfPresent()
+		if err != nil {
+			p.logger.Error("Error enqueueing delete of DNS record", "key", key, "error", err)
+		}
 	}
 }
 
@@ -183,21 +303,11 @@ func (p *plugin) nextEntityId() int {
 	return p.entityCounter
 }
 
-func (p *plugin) poll(ctx context.Context) {
-	// Initial pause
-	timer := time.NewTimer(20 * time.Second)
+// pollCheckInterval is how often the poll schedules of the records and domains are checked for due refreshes.
+const pollCheckInterval = 10 * time.Second
 
-	select {
-	case <-ctx.Done():
-		timer.Stop()
-		return
-	case <-timer.C:
-	}
-
-	p.enqueueRefresh()
-
-	// Refresh every 4 hours
-	ticker := time.NewTicker(4 * time.Hour)
+func (p *plugin) poll(ctx context.Context) {
+	ticker := time.NewTicker(pollCheckInterval)
 	defer ticker.Stop()
 
 	for {
@@ -212,27 +322,60 @@ func (p *plugin) poll(ctx context.Context) {
 
 func (p *plugin) enqueueRefresh() {
 	err := p.container.EnqueueOnPluginGoRoutine(func() {
-		refreshError := p.refresh()
+		refreshError := p.refreshDue(time.Now())
 
 		if refreshError != nil {
-			fmt.Printf("%T: Error enqueueing refresh: %v\n", p, refreshError)
+			p.logger.Error("Error enqueueing refresh", "error", refreshError)
 		}
 	})
 
 	if err != nil {
-		fmt.Printf("%T: Unable to enque refresh\n", p)
+		p.logger.Error("Unable to enqueue refresh", "error", err)
+	}
+}
+
+// startPolling schedules the first refresh of the records, and discovers the records of the domains that discover
+// records right away, so their entities are available without waiting for the initial delay.
+func (p *plugin) startPolling() {
+	now := time.Now()
+
+	for domain, schedule := range p.domainPollSchedules {
+		schedule.Refreshed(now)
+
+		if err := p.enqueueDomainDiscovery(domain); err != nil {
+			p.logger.Error("Error enqueueing domain discovery", "domain", domain, "error", err)
+			schedule.Failed(now)
+		}
+	}
+
+	for _, record := range p.dnsRecords {
+		record.StartPolling(now)
 	}
 }
 
-func (p *plugin) refresh() error {
+// refreshDue refreshes the records and domains whose poll schedule says a refresh is due.
+func (p *plugin) refreshDue(now time.Time) error {
 	if !p.running {
 		return fmt.Errorf("plugin is not running")
 	}
 
 	errors := make([]error, 0)
 
+	for domain, schedule := range p.domainPollSchedules {
+		if !schedule.Due(now) {
+			continue
+		}
+
+		err := p.enqueueDomainDiscovery(domain)
+
+		if err != nil {
+			schedule.Failed(now)
+			errors = append(errors, err)
+		}
+	}
+
 	for _, record := range p.dnsRecords {
-		err := record.Refresh()
+		err := record.RefreshIfDue(now)
 
 		if err != nil {
 			errors = append(errors, err)
@@ -246,14 +389,12 @@ func (p *plugin) refresh() error {
 	return nil
 }
 
-// enqueueRequest sends a request to the plugin's worker(s) if the plugin is running.
-// Returns an error if unable to add to the queue.  Must be called from the main plugin goroutine since it
-// reads the running state of the plugin.
 func (p *plugin) enqueueRequest(request common.Request) error {
 	if !p.running {
 		return fmt.Errorf("unable to enqueue request, plugin is not running")
 	}
 
+	request.Retry.FirstAttemptTime = time.Now()
 	err := p.requestRetryingQueue.Enqueue(&request)
 
 	if err != nil {
@@ -268,7 +409,8 @@ func (p *plugin) enqueueRequest(request common.Request) error {
 func (p *plugin) getStatusAndEntities() common.StatusAndEntities {
 	queStats := p.requestQueue.Stats()
 	retryQueueStats := p.requestRetryingQueue.Stats()
-	var totalSuccessCount, totalErrorCount int
+	rateLimiterStats := p.rateLimiter.Stats()
+	var totalSuccessCount, totalErrorCount, totalReconcileCount int
 	var lastError error
 	var lastErrorMessage string
 	var lastErrorTime time.Time
@@ -279,8 +421,11 @@ func (p *plugin) getStatusAndEntities() common.StatusAndEntities {
 	for _, entity := range p.dnsRecords {
 		entityData := entity.Data()
 		dnsRecordDatas[i] = entityData
-		totalSuccessCount = totalSuccessCount + entityData.GetSuccessCount + entityData.UpdateSuccessCount
-		totalErrorCount = totalErrorCount + entityData.GetErrorCount + entityData.UpdateErrorCount
+		totalSuccessCount = totalSuccessCount + entityData.GetSuccessCount + entityData.UpdateSuccessCount +
+			entityData.DeleteSuccessCount
+		totalErrorCount = totalErrorCount + entityData.GetErrorCount + entityData.UpdateErrorCount +
+			entityData.DeleteErrorCount
+		totalReconcileCount = totalReconcileCount + entityData.ReconcileCount
 
 		if entityData.LastErrorTime.After(lastErrorTime) {
 			lastErrorTime = entityData.LastErrorTime
@@ -304,11 +449,149 @@ func (p *plugin) getStatusAndEntities() common.StatusAndEntities {
 			PeakRetryQueueSizeTime: retryQueueStats.PeakCountTime,
 			PeakFailedAttempts:     retryQueueStats.PeakFailedAttempts,
 			PeakFailedAttemptsTime: retryQueueStats.PeakFailedAttemptsTime,
+			ThrottledCount:         rateLimiterStats.ThrottledCount,
+			ThrottledTime:          rateLimiterStats.ThrottledTime,
+			RateLimitedCount:       rateLimiterStats.RateLimitedCount,
+			LastRateLimitedTime:    rateLimiterStats.LastRateLimitedTime,
+			RateLimitedUntil:       rateLimiterStats.PausedUntil,
 			TotalSuccessCount:      totalSuccessCount,
 			TotalErrorCount:        totalErrorCount,
+			TotalReconcileCount:    totalReconcileCount,
 			LastErrorMessage:       lastErrorMessage,
 			LastErrorTime:          lastErrorTime,
+			PublicIpV4:             addrString(p.lastPublicIpResult.IpV4),
+			PublicIpV6:             addrString(p.lastPublicIpResult.IpV6),
+			PublicIpDetectionTime:  p.lastPublicIpResult.DetectionTime,
+			PublicIpErrorMessage:   errorString(p.lastPublicIpResult.Error),
+			ApiEndpoints:           p.apiMetrics.Stats(),
+			DualStackRecords:       p.dualStackRecordDatas(),
 		},
 		DnsRecords: dnsRecordDatas,
 	}
 }
+
+// findDnsRecord returns the record with the passed domain, type and name.  Must be called from the main plugin
+// goroutine.
+func (p *plugin) findDnsRecord(domain string, recordType string, name string) (*dnsRecord.DnsRecord, error) {
+	for _, record := range p.dnsRecords {
+		recordData := record.Data()
+
+		if record.Domain() == domain && recordData.Type == recordType && recordData.Name == name {
+			return record, nil
+		}
+	}
+
+	return nil, fmt.Errorf("DNS record %s %s %s: %w", domain, recordType, name, common.ErrDnsRecordNotFound)
+}
+
+// getDnsRecordHistory returns the data and change history of a record.  It does not perform any synchronization, so it
+// should only be called from the plugin's main GoRoutine.
+func (p *plugin) getDnsRecordHistory(domain string, recordType string, name string) (common.DnsRecordHistory, error) {
+	record, err := p.findDnsRecord(domain, recordType, name)
+
+	if err != nil {
+		return common.DnsRecordHistory{}, err
+	}
+
+	return common.DnsRecordHistory{Data: record.Data(), Changes: record.History()}, nil
+}
+
+// refreshDnsRecord refreshes a record immediately, regardless of its poll schedule.  A discovered record is refreshed
+// by retrieving its domain.  Must be called from the main plugin goroutine.
+func (p *plugin) refreshDnsRecord(domain string, recordType string, name string) error {
+	if !p.running {
+		return fmt.Errorf("plugin is not running")
+	}
+
+	record, err := p.findDnsRecord(domain, recordType, name)
+
+	if err != nil {
+		return err
+	}
+
+	if record.Discovered() {
+		return p.enqueueDomainDiscovery(domain)
+	}
+
+	return record.Refresh()
+}
+
+// refreshAll refreshes all the records and the domains that discover records immediately, regardless of their poll
+// schedule.  Must be called from the main plugin goroutine.
+func (p *plugin) refreshAll() error {
+	if !p.running {
+		return fmt.Errorf("plugin is not running")
+	}
+
+	errs := make([]error, 0)
+
+	for domain := range p.domainPollSchedules {
+		errs = append(errs, p.enqueueDomainDiscovery(domain))
+	}
+
+	for _, record := range p.dnsRecords {
+		errs = append(errs, record.Refresh())
+	}
+
+	return errors.Join(errs...)
+}
+
+// startDnsRecordUpdate starts an update of a record, applying the patch to its current spec.  Must be called from the
+// main plugin goroutine.
+func (p *plugin) startDnsRecordUpdate(
+	ctx context.Context,
+	domain string,
+	recordType string,
+	name string,
+	patch common.DnsRecordPatch) (<-chan data.DnsRecordUpdateResult, error) {
+	if !p.running {
+		return nil, fmt.Errorf("plugin is not running")
+	}
+
+	record, err := p.findDnsRecord(domain, recordType, name)
+
+	if err != nil {
+		return nil, err
+	}
+
+	recordData := record.Data()
+	spec := data.DnsRecordSpec{
+		Value:    recordData.Value,
+		Ttl:      recordData.Ttl,
+		Priority: recordData.Priority,
+		Notes:    recordData.Notes,
+	}
+
+	if patch.Value != nil {
+		spec.Value = *patch.Value
+	}
+
+	if patch.Ttl != nil {
+		spec.Ttl = *patch.Ttl
+	}
+
+	if patch.Priority != nil {
+		spec.Priority = *patch.Priority
+	}
+
+	if patch.Notes != nil {
+		spec.Notes = *patch.Notes
+	}
+
+	return record.UpdateAsync(ctx, spec)
+}
+
+// deleteDnsRecord deletes a record from its domain.  Must be called from the main plugin goroutine.
+func (p *plugin) deleteDnsRecord(domain string, recordType string, name string) error {
+	if !p.running {
+		return fmt.Errorf("plugin is not running")
+	}
+
+	record, err := p.findDnsRecord(domain, recordType, name)
+
+	if err != nil {
+		return err
+	}
+
+	return record.Delete()
+}
@@ -0,0 +1,69 @@
+package porkbuntest
+
+type requestMessageKey struct{}
+
+// requestMessage combines the credentials and record fields accepted by the API endpoints.  The optional fields are
+// pointers, so that edits can keep the fields the request leaves out.
+type requestMessage struct {
+	SecretApiKey string  `json:"secretapikey"`
+	ApiKey       string  `json:"apikey"`
+	Name         *string `json:"name"`
+	Type         string  `json:"type"`
+	Content      string  `json:"content"`
+	Ttl          *string `json:"ttl"`
+	Prio         *string `json:"prio"`
+	Notes        *string `json:"notes"`
+}
+
+func (m *requestMessage) toRecord() Record {
+	return m.applyTo(Record{})
+}
+
+// applyTo returns the record with the fields of the request applied, keeping the optional fields the request leaves
+// out.
+func (m *requestMessage) applyTo(record Record) Record {
+	record.Type = m.Type
+	record.Content = m.Content
+	applyOptional(&record.Name, m.Name)
+	applyOptional(&record.Ttl, m.Ttl)
+	applyOptional(&record.Prio, m.Prio)
+	applyOptional(&record.Notes, m.Notes)
+
+	return record
+}
+
+func applyOptional(field *string, value *string) {
+	if value != nil {
+		*field = *value
+	}
+}
+
+type statusMessage struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+type recordMessage struct {
+	Id      string `json:"id"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	Content string `json:"content"`
+	Ttl     string `json:"ttl"`
+	Prio    string `json:"prio"`
+	Notes   string `json:"notes"`
+}
+
+type retrieveResponseMessage struct {
+	statusMessage
+	Records []recordMessage `json:"records"`
+}
+
+type createResponseMessage struct {
+	statusMessage
+	Id int `json:"id"`
+}
+
+type pingResponseMessage struct {
+	statusMessage
+	YourIp string `json:"yourIp"`
+}
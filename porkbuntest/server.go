@@ -0,0 +1,479 @@
+// Package porkbuntest provides an in-memory stand-in for the Porkbun JSON v3 API, for use in tests of assemblies
+// that use the porkbun plugin.
+//
+// Point the plugin at the fake by setting PluginConfig.ApiBaseUrl to Server.ApiBaseUrl() and
+// PluginConfig.HttpClient to Server.Client():
+//
+//	server := porkbuntest.NewServer("apiKey", "apiSecret")
+//	defer server.Close()
+//	server.AddRecord("example.com", porkbuntest.Record{Type: "A", Name: "www", Content: "192.0.2.1"})
+//	conf := porkbun.NewPluginConfig()
+//	conf.ApiKey = "apiKey"
+//	conf.ApiSecret = "apiSecret"
+//	conf.ApiBaseUrl = server.ApiBaseUrl()
+//	conf.HttpClient = server.Client()
+package porkbuntest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ApiPath is the path of the API on the fake server, matching the path of the real API.
+const ApiPath = "/api/json/v3"
+
+const defaultTtl = "600"
+const defaultPrio = "0"
+
+// Record is a DNS record held by the fake server.  Name is relative to the domain, and empty for records at the
+// domain apex.
+type Record struct {
+	Id      string
+	Name    string
+	Type    string
+	Content string
+	Ttl     string
+	Prio    string
+	Notes   string
+}
+
+// Fault describes a failure the server injects into responses.
+type Fault struct {
+	// Endpoint limits the fault to requests for the endpoint with this prefix, for example "dns/edit".  An empty
+	// Endpoint matches all requests.
+	Endpoint string
+	// Times is the number of requests the fault applies to.  Zero applies the fault until ClearFaults is called.
+	Times int
+	// Latency delays the response.
+	Latency time.Duration
+	// HttpStatus, if non-zero, is returned instead of processing the request, along with Body.
+	HttpStatus int
+	// Body is returned verbatim instead of processing the request, which allows returning malformed JSON.
+	Body string
+	// RetryAfter, if set, is returned as the Retry-After header along with HttpStatus.
+	RetryAfter string
+	// ErrorMessage, if set, is returned as a "status":"ERROR" response, instead of processing the request.
+	ErrorMessage string
+}
+
+type Server struct {
+	server        *httptest.Server
+	apiKey        string
+	apiSecret     string
+	mu            sync.Mutex
+	pingIp        string
+	domains       map[string][]Record
+	nextId        int
+	faults        []*Fault
+	requestCounts map[string]int
+}
+
+// NewServer starts a fake API server that accepts requests with the passed credentials.  Callers must call Close when
+// done with the server.
+func NewServer(apiKey string, apiSecret string) *Server {
+	s := &Server{
+		apiKey:        apiKey,
+		apiSecret:     apiSecret,
+		domains:       make(map[string][]Record),
+		nextId:        100000000,
+		requestCounts: make(map[string]int),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+ApiPath+"/ping", s.handlePing)
+	mux.HandleFunc("POST "+ApiPath+"/dns/retrieve/{domain}", s.handleRetrieve)
+	mux.HandleFunc("POST "+ApiPath+"/dns/retrieveByNameType/{domain}/{type}/{name...}", s.handleRetrieveByNameType)
+	mux.HandleFunc("POST "+ApiPath+"/dns/create/{domain}", s.handleCreate)
+	mux.HandleFunc("POST "+ApiPath+"/dns/edit/{domain}/{id}", s.handleEdit)
+	mux.HandleFunc("POST "+ApiPath+"/dns/delete/{domain}/{id}", s.handleDelete)
+	mux.HandleFunc("POST "+ApiPath+"/dns/deleteByNameType/{domain}/{type}/{name...}", s.handleDeleteByNameType)
+	s.server = httptest.NewServer(s.withFaultsAndCredentials(mux))
+
+	return s
+}
+
+// Close shuts down the server.
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// URL returns the base URL of the server.
+func (s *Server) URL() string {
+	return s.server.URL
+}
+
+// ApiBaseUrl returns the value to use for PluginConfig.ApiBaseUrl.
+func (s *Server) ApiBaseUrl() string {
+	return s.server.URL + ApiPath
+}
+
+// Client returns an HTTP client configured to talk to the server.  It satisfies spicommon.HttpClient.
+func (s *Server) Client() *http.Client {
+	return s.server.Client()
+}
+
+// AddDomain adds an empty domain to the server.  Requests for domains that were never added fail.
+func (s *Server) AddDomain(domain string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.domains[domain]; !ok {
+		s.domains[domain] = make([]Record, 0)
+	}
+}
+
+// AddRecord adds a record to the passed domain, adding the domain if necessary, and returns the id assigned to the
+// record.
+func (s *Server) AddRecord(domain string, record Record) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.addRecordLocked(domain, record)
+}
+
+// Records returns a copy of the records in the passed domain.
+func (s *Server) Records(domain string) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.domains[domain])
+}
+
+// FindRecord returns the first record in the domain with the passed type and name.
+func (s *Server) FindRecord(domain string, recordType string, name string) (Record, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, record := range s.domains[domain] {
+		if record.Type == recordType && record.Name == name {
+			return record, true
+		}
+	}
+
+	return Record{}, false
+}
+
+// SetPingIp sets the address returned by the ping endpoint.  By default, the endpoint returns the remote address of
+// the request.
+func (s *Server) SetPingIp(ip string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pingIp = ip
+}
+
+// AddFault registers a fault to inject into subsequent responses.  Faults are evaluated in the order they were added,
+// and only the first matching fault applies to a request.
+func (s *Server) AddFault(fault Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &fault)
+}
+
+// ClearFaults removes all registered faults.
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// RequestCount returns the number of requests received for endpoints with the passed prefix, for example "dns/edit".
+// Pass an empty string to count all requests.
+func (s *Server) RequestCount(endpoint string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count := 0
+
+	for path, pathCount := range s.requestCounts {
+		if strings.HasPrefix(path, endpoint) {
+			count = count + pathCount
+		}
+	}
+
+	return count
+}
+
+func (s *Server) withFaultsAndCredentials(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		endpoint := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, ApiPath), "/")
+		fault := s.recordRequestAndFindFault(endpoint)
+
+		if fault != nil {
+			if fault.Latency > 0 {
+				time.Sleep(fault.Latency)
+			}
+
+			if fault.HttpStatus != 0 || fault.Body != "" {
+				status := fault.HttpStatus
+
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+
+				if fault.RetryAfter != "" {
+					w.Header().Set("Retry-After", fault.RetryAfter)
+				}
+
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(fault.Body))
+				return
+			}
+
+			if fault.ErrorMessage != "" {
+				writeError(w, http.StatusBadRequest, fault.ErrorMessage)
+				return
+			}
+		}
+
+		var body requestMessage
+
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Sprintf("Unable to parse request body: %v", err))
+			return
+		}
+
+		if body.ApiKey != s.apiKey || body.SecretApiKey != s.apiSecret {
+			writeError(w, http.StatusBadRequest, "Invalid API key. (002)")
+			return
+		}
+
+		// The body can only be read once, so pass the record fields to the handlers via the context
+		r = r.WithContext(context.WithValue(r.Context(), requestMessageKey{}, &body))
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) recordRequestAndFindFault(endpoint string) *Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requestCounts[endpoint]++
+
+	for i, fault := range s.faults {
+		if !strings.HasPrefix(endpoint, fault.Endpoint) {
+			continue
+		}
+
+		if fault.Times > 0 {
+			fault.Times--
+
+			if fault.Times == 0 {
+				s.faults = slices.Delete(s.faults, i, i+1)
+			}
+		}
+
+		return fault
+	}
+
+	return nil
+}
+
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	ip := s.pingIp
+	s.mu.Unlock()
+
+	if ip == "" {
+		ip, _, _ = net.SplitHostPort(r.RemoteAddr)
+	}
+
+	writeJson(w, pingResponseMessage{statusMessage: successMessage(), YourIp: ip})
+}
+
+func (s *Server) handleRetrieve(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	s.mu.Lock()
+	records, ok := s.domains[domain]
+	responseRecords := toRecordMessages(domain, records)
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid domain.")
+		return
+	}
+
+	writeJson(w, retrieveResponseMessage{statusMessage: successMessage(), Records: responseRecords})
+}
+
+func (s *Server) handleRetrieveByNameType(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	recordType := r.PathValue("type")
+	name := r.PathValue("name")
+	s.mu.Lock()
+	records, ok := s.domains[domain]
+	matching := make([]Record, 0)
+
+	for _, record := range records {
+		if record.Type == recordType && record.Name == name {
+			matching = append(matching, record)
+		}
+	}
+
+	responseRecords := toRecordMessages(domain, matching)
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid domain.")
+		return
+	}
+
+	writeJson(w, retrieveResponseMessage{statusMessage: successMessage(), Records: responseRecords})
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	message := r.Context().Value(requestMessageKey{}).(*requestMessage)
+
+	if message.Type == "" || message.Content == "" {
+		writeError(w, http.StatusBadRequest, "Create error: type and content are required.")
+		return
+	}
+
+	s.mu.Lock()
+	if _, ok := s.domains[domain]; !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, "Invalid domain.")
+		return
+	}
+
+	id := s.addRecordLocked(domain, message.toRecord())
+	s.mu.Unlock()
+	numericId, _ := strconv.Atoi(id)
+
+	writeJson(w, createResponseMessage{statusMessage: successMessage(), Id: numericId})
+}
+
+func (s *Server) handleEdit(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	id := r.PathValue("id")
+	message := r.Context().Value(requestMessageKey{}).(*requestMessage)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := s.domains[domain]
+	index := slices.IndexFunc(records, func(record Record) bool { return record.Id == id })
+
+	if index < 0 {
+		writeError(w, http.StatusBadRequest, "Edit error: Invalid record ID.")
+		return
+	}
+
+	// Like the real API, an edit without a name moves the record to the domain apex, and an edit without a TTL resets
+	// it to the default.  The other fields left out of the request are kept.
+	record := message.applyTo(records[index])
+
+	if message.Name == nil {
+		record.Name = ""
+	}
+
+	if message.Ttl == nil {
+		record.Ttl = defaultTtl
+	}
+
+	records[index] = record
+	writeJson(w, successMessage())
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	id := r.PathValue("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := s.domains[domain]
+	index := slices.IndexFunc(records, func(record Record) bool { return record.Id == id })
+
+	if index < 0 {
+		writeError(w, http.StatusBadRequest, "Delete error: Invalid record ID.")
+		return
+	}
+
+	s.domains[domain] = slices.Delete(records, index, index+1)
+	writeJson(w, successMessage())
+}
+
+func (s *Server) handleDeleteByNameType(w http.ResponseWriter, r *http.Request) {
+	domain := r.PathValue("domain")
+	recordType := r.PathValue("type")
+	name := r.PathValue("name")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records, ok := s.domains[domain]
+
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Invalid domain.")
+		return
+	}
+
+	s.domains[domain] = slices.DeleteFunc(records, func(record Record) bool {
+		return record.Type == recordType && record.Name == name
+	})
+	writeJson(w, successMessage())
+}
+
+func (s *Server) addRecordLocked(domain string, record Record) string {
+	s.nextId++
+	record.Id = strconv.Itoa(s.nextId)
+
+	if record.Ttl == "" {
+		record.Ttl = defaultTtl
+	}
+
+	if record.Prio == "" {
+		record.Prio = defaultPrio
+	}
+
+	s.domains[domain] = append(s.domains[domain], record)
+
+	return record.Id
+}
+
+func toRecordMessages(domain string, records []Record) []recordMessage {
+	messages := make([]recordMessage, len(records))
+
+	for i, record := range records {
+		// The API returns fully qualified names
+		name := domain
+
+		if record.Name != "" {
+			name = record.Name + "." + domain
+		}
+
+		messages[i] = recordMessage{
+			Id:      record.Id,
+			Name:    name,
+			Type:    record.Type,
+			Content: record.Content,
+			Ttl:     record.Ttl,
+			Prio:    record.Prio,
+			Notes:   record.Notes,
+		}
+	}
+
+	return messages
+}
+
+func successMessage() statusMessage {
+	return statusMessage{Status: "SUCCESS"}
+}
+
+func writeError(w http.ResponseWriter, httpStatus int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	_ = json.NewEncoder(w).Encode(statusMessage{Status: "ERROR", Message: message})
+}
+
+func writeJson(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
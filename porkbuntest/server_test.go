@@ -0,0 +1,266 @@
+package porkbuntest
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+)
+
+const testDomain = "example.com"
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	server := NewServer("apiKey", "apiSecret")
+	t.Cleanup(server.Close)
+	server.AddDomain(testDomain)
+
+	return server
+}
+
+// post calls the endpoint with the credentials and the passed fields, and decodes the response into response.  It
+// returns the HTTP status code of the response.
+func post(t *testing.T, server *Server, endpoint string, fields map[string]any, response any) int {
+	t.Helper()
+	body := map[string]any{"apikey": "apiKey", "secretapikey": "apiSecret"}
+
+	for key, value := range fields {
+		body[key] = value
+	}
+
+	bodyBytes, err := json.Marshal(body)
+
+	if err != nil {
+		t.Fatalf("unable to serialize request: %v", err)
+	}
+
+	httpResponse, err := server.Client().Post(server.ApiBaseUrl()+"/"+endpoint, "application/json",
+		bytes.NewReader(bodyBytes))
+
+	if err != nil {
+		t.Fatalf("error calling %s: %v", endpoint, err)
+	}
+
+	defer httpResponse.Body.Close()
+
+	if response != nil {
+		if err = json.NewDecoder(httpResponse.Body).Decode(response); err != nil {
+			t.Fatalf("unable to parse %s response: %v", endpoint, err)
+		}
+	}
+
+	return httpResponse.StatusCode
+}
+
+func TestRetrieveReturnsFullyQualifiedNames(t *testing.T) {
+	server := newTestServer(t)
+	id := server.AddRecord(testDomain, Record{Type: "A", Content: "192.0.2.1"})
+	server.AddRecord(testDomain, Record{Type: "A", Name: "www", Content: "192.0.2.2", Ttl: "300"})
+	response := retrieveResponseMessage{}
+
+	if status := post(t, server, "dns/retrieve/"+testDomain, nil, &response); status != http.StatusOK {
+		t.Fatalf("got HTTP %d, want 200", status)
+	}
+
+	if len(response.Records) != 2 {
+		t.Fatalf("got %d records, want 2", len(response.Records))
+	}
+
+	expected := recordMessage{Id: id, Name: testDomain, Type: "A", Content: "192.0.2.1", Ttl: "600", Prio: "0"}
+
+	if response.Records[0] != expected {
+		t.Errorf("got %+v, want %+v", response.Records[0], expected)
+	}
+
+	if name := response.Records[1].Name; name != "www."+testDomain {
+		t.Errorf("got name %q, want %q", name, "www."+testDomain)
+	}
+}
+
+func TestRetrieveByNameType(t *testing.T) {
+	server := newTestServer(t)
+	server.AddRecord(testDomain, Record{Type: "A", Name: "www", Content: "192.0.2.1"})
+	server.AddRecord(testDomain, Record{Type: "AAAA", Name: "www", Content: "2001:db8::1"})
+	server.AddRecord(testDomain, Record{Type: "A", Name: "mail", Content: "192.0.2.2"})
+	response := retrieveResponseMessage{}
+	post(t, server, "dns/retrieveByNameType/"+testDomain+"/A/www", nil, &response)
+
+	if len(response.Records) != 1 || response.Records[0].Content != "192.0.2.1" {
+		t.Errorf("got %+v, want only the www A record", response.Records)
+	}
+}
+
+func TestCreate(t *testing.T) {
+	server := newTestServer(t)
+	response := createResponseMessage{}
+	post(t, server, "dns/create/"+testDomain,
+		map[string]any{"type": "TXT", "name": "info", "content": "hello", "notes": "note"}, &response)
+
+	if response.Status != "SUCCESS" || response.Id == 0 {
+		t.Fatalf("got %+v, want success with an id", response)
+	}
+
+	record, ok := server.FindRecord(testDomain, "TXT", "info")
+	expected := Record{Id: record.Id, Name: "info", Type: "TXT", Content: "hello", Ttl: "600", Prio: "0",
+		Notes: "note"}
+
+	if !ok || record != expected {
+		t.Errorf("got %+v, want %+v", record, expected)
+	}
+}
+
+func TestEditKeepsOmittedFields(t *testing.T) {
+	server := newTestServer(t)
+	id := server.AddRecord(testDomain,
+		Record{Type: "MX", Name: "www", Content: "mail.example.com", Ttl: "3600", Prio: "10", Notes: "web"})
+	response := statusMessage{}
+	post(t, server, "dns/edit/"+testDomain+"/"+id,
+		map[string]any{"type": "MX", "name": "www", "content": "mx.example.com", "ttl": "3600"}, &response)
+
+	if response.Status != "SUCCESS" {
+		t.Fatalf("got %+v, want success", response)
+	}
+
+	record, _ := server.FindRecord(testDomain, "MX", "www")
+	expected := Record{Id: id, Type: "MX", Name: "www", Content: "mx.example.com", Ttl: "3600", Prio: "10",
+		Notes: "web"}
+
+	if record != expected {
+		t.Errorf("got %+v, want %+v", record, expected)
+	}
+}
+
+func TestEditAppliesDocumentedDefaults(t *testing.T) {
+	server := newTestServer(t)
+	id := server.AddRecord(testDomain, Record{Type: "A", Name: "www", Content: "192.0.2.1", Ttl: "3600"})
+	post(t, server, "dns/edit/"+testDomain+"/"+id, map[string]any{"type": "A", "content": "192.0.2.2"}, nil)
+
+	records := server.Records(testDomain)
+
+	if len(records) != 1 || records[0].Name != "" || records[0].Ttl != defaultTtl {
+		t.Errorf("got %+v, want the record moved to the apex with the default TTL", records)
+	}
+}
+
+func TestEditAndDeleteOfUnknownId(t *testing.T) {
+	server := newTestServer(t)
+
+	for _, endpoint := range []string{"dns/edit/" + testDomain + "/1", "dns/delete/" + testDomain + "/1"} {
+		response := statusMessage{}
+		status := post(t, server, endpoint, map[string]any{"type": "A", "content": "192.0.2.1"}, &response)
+
+		if status != http.StatusBadRequest || response.Status != "ERROR" {
+			t.Errorf("%s: got HTTP %d and %+v, want an error", endpoint, status, response)
+		}
+	}
+}
+
+func TestDelete(t *testing.T) {
+	server := newTestServer(t)
+	id := server.AddRecord(testDomain, Record{Type: "A", Name: "www", Content: "192.0.2.1"})
+	server.AddRecord(testDomain, Record{Type: "A", Name: "www", Content: "192.0.2.2"})
+	post(t, server, "dns/delete/"+testDomain+"/"+id, nil, nil)
+
+	records := server.Records(testDomain)
+
+	if len(records) != 1 || records[0].Content != "192.0.2.2" {
+		t.Errorf("got %+v, want only the second record", records)
+	}
+}
+
+func TestDeleteByNameType(t *testing.T) {
+	server := newTestServer(t)
+	server.AddRecord(testDomain, Record{Type: "A", Name: "www", Content: "192.0.2.1"})
+	server.AddRecord(testDomain, Record{Type: "A", Name: "www", Content: "192.0.2.2"})
+	server.AddRecord(testDomain, Record{Type: "AAAA", Name: "www", Content: "2001:db8::1"})
+	post(t, server, "dns/deleteByNameType/"+testDomain+"/A/www", nil, nil)
+
+	records := server.Records(testDomain)
+
+	if len(records) != 1 || records[0].Type != "AAAA" {
+		t.Errorf("got %+v, want only the AAAA record", records)
+	}
+}
+
+func TestInvalidCredentialsAndDomain(t *testing.T) {
+	server := newTestServer(t)
+	response := statusMessage{}
+	status := post(t, server, "dns/retrieve/"+testDomain, map[string]any{"secretapikey": "wrong"}, &response)
+
+	if status != http.StatusBadRequest || response.Message != "Invalid API key. (002)" {
+		t.Errorf("got HTTP %d and %+v, want an invalid API key error", status, response)
+	}
+
+	response = statusMessage{}
+	status = post(t, server, "dns/retrieve/example.org", nil, &response)
+
+	if status != http.StatusBadRequest || response.Message != "Invalid domain." {
+		t.Errorf("got HTTP %d and %+v, want an invalid domain error", status, response)
+	}
+}
+
+func TestPing(t *testing.T) {
+	server := newTestServer(t)
+	response := pingResponseMessage{}
+	post(t, server, "ping", nil, &response)
+
+	if response.YourIp != "127.0.0.1" {
+		t.Errorf("got %q, want the remote address", response.YourIp)
+	}
+
+	server.SetPingIp("203.0.113.1")
+	post(t, server, "ping", nil, &response)
+
+	if response.YourIp != "203.0.113.1" {
+		t.Errorf("got %q, want the address that was set", response.YourIp)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	server := newTestServer(t)
+	server.AddFault(Fault{Endpoint: "dns/edit", ErrorMessage: "Edit failed."})
+	server.AddFault(Fault{Times: 1, HttpStatus: http.StatusTooManyRequests, RetryAfter: "5", Body: "{}"})
+
+	// The edit fault doesn't match, so the first request gets the second fault, which is then removed
+	httpResponse, err := server.Client().Post(server.ApiBaseUrl()+"/ping", "application/json", nil)
+
+	if err != nil {
+		t.Fatalf("error calling ping: %v", err)
+	}
+
+	_ = httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusTooManyRequests || httpResponse.Header.Get("Retry-After") != "5" {
+		t.Errorf("got HTTP %d with Retry-After %q, want 429 with 5", httpResponse.StatusCode,
+			httpResponse.Header.Get("Retry-After"))
+	}
+
+	if status := post(t, server, "ping", nil, nil); status != http.StatusOK {
+		t.Errorf("got HTTP %d after the fault was used up, want 200", status)
+	}
+
+	response := statusMessage{}
+	post(t, server, "dns/edit/"+testDomain+"/1", nil, &response)
+
+	if response.Message != "Edit failed." {
+		t.Errorf("got %+v, want the injected error", response)
+	}
+
+	server.ClearFaults()
+	server.AddFault(Fault{Times: 1, Latency: 50 * time.Millisecond})
+	start := time.Now()
+	post(t, server, "ping", nil, nil)
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("got a response after %v, want the injected latency", elapsed)
+	}
+
+	if count := server.RequestCount("ping"); count != 3 {
+		t.Errorf("got %d ping requests, want 3", count)
+	}
+
+	if count := server.RequestCount(""); count != 4 {
+		t.Errorf("got %d requests, want 4", count)
+	}
+}
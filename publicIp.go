@@ -0,0 +1,127 @@
+package porkbun
+
+import (
+	"context"
+	"net/http"
+	"net/netip"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/config"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/publicip"
+	spicommon "github.com/avanha/pmaas-spi/common"
+)
+
+func newPublicIpDetector(
+	pluginConfig *config.PluginConfig,
+	apiBaseUrl string,
+	enqueueRequest func(request common.Request) error) *publicip.Detector {
+	pingUrls := []string{apiBaseUrl + "/ping"}
+	ipV4PingUrl := pluginConfig.PublicIp.IpV4PingUrl
+
+	// The default IPv4 ping endpoint is on the real API, so it's only used along with the real API
+	if ipV4PingUrl != "" && (ipV4PingUrl != config.DefaultIpV4PingUrl || apiBaseUrl == config.DefaultApiBaseUrl) {
+		pingUrls = append(pingUrls, ipV4PingUrl)
+	}
+
+	var httpClient spicommon.HttpClient = pluginConfig.HttpClient
+
+	if httpClient == nil {
+		// Unlike spicommon.DefaultHttpClient, allows calls to the echo URLs to time out
+		httpClient = http.DefaultClient
+	}
+
+	timeout := pluginConfig.PublicIp.Timeout
+
+	if timeout <= 0 {
+		timeout = config.DefaultPublicIpConfig().Timeout
+	}
+
+	return publicip.NewDetector(
+		enqueueRequest,
+		pingUrls,
+		pluginConfig.PublicIp.EchoUrls,
+		httpClient,
+		timeout)
+}
+
+// pollPublicIp periodically detects the public IP addresses of the host, and passes the result to the plugin
+// goroutine.  Runs on its own goroutine, until the passed context is cancelled.
+func (p *plugin) pollPublicIp(ctx context.Context) {
+	interval := p.config.PublicIp.Interval
+
+	if interval <= 0 {
+		interval = config.DefaultPublicIpConfig().Interval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result := p.publicIpDetector.Detect(ctx)
+		err := p.container.EnqueueOnPluginGoRoutine(func() { p.processPublicIpResult(result) })
+
+		if err != nil {
+			p.logger.Error("Unable to enqueue public IP result processing", "error", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// processPublicIpResult updates the records that track the public IP, if their value differs from the detected
+// address.  Must be called from the main plugin goroutine.
+func (p *plugin) processPublicIpResult(result publicip.Result) {
+	p.lastPublicIpResult = result
+
+	if result.Error != nil {
+		p.logger.Warn("Unable to detect the public IP", "error", result.Error)
+		return
+	}
+
+	if !p.running {
+		return
+	}
+
+	for _, record := range p.publicIpRecords {
+		addr := result.IpV4
+		recordData := record.Data()
+
+		if recordData.Type == "AAAA" {
+			addr = result.IpV6
+		}
+
+		if !addr.IsValid() || recordData.Value == addr.String() {
+			continue
+		}
+
+		p.logger.Info("Public IP of DNS record changed", "domain", record.Domain(), "type", recordData.Type,
+			"name", recordData.Name, "old_value", recordData.Value, "new_value", addr.String())
+		err := record.UpdateValue(addr.String())
+
+		if err != nil {
+			p.logger.Error("Error updating DNS record with public IP", "domain", record.Domain(),
+				"type", recordData.Type, "name", recordData.Name, "error", err)
+		}
+	}
+}
+
+func addrString(addr netip.Addr) string {
+	if !addr.IsValid() {
+		return ""
+	}
+
+	return addr.String()
+}
+
+func errorString(err error) string {
+	if err == nil {
+		return ""
+	}
+
+	return err.Error()
+}
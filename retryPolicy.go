@@ -0,0 +1,119 @@
+package porkbun
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/config"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+)
+
+// retryPolicy decides whether failed requests are retried, and when.  The policy implements backoff by stamping the
+// next attempt time on the request, which the retrying queue holds the request until.
+type retryPolicy struct {
+	config config.RetryConfig
+	logger *slog.Logger
+}
+
+func newRetryPolicy(retryConfig config.RetryConfig, logger *slog.Logger) *retryPolicy {
+	defaults := config.DefaultRetryConfig()
+
+	if retryConfig.MaxAttempts <= 0 {
+		retryConfig.MaxAttempts = defaults.MaxAttempts
+	}
+
+	if retryConfig.InitialInterval <= 0 {
+		retryConfig.InitialInterval = defaults.InitialInterval
+	}
+
+	if retryConfig.MaxInterval <= 0 {
+		retryConfig.MaxInterval = defaults.MaxInterval
+	}
+
+	if retryConfig.Multiplier < 1 {
+		retryConfig.Multiplier = defaults.Multiplier
+	}
+
+	if retryConfig.Jitter <= 0 || retryConfig.Jitter > 1 {
+		retryConfig.Jitter = defaults.Jitter
+	}
+
+	if retryConfig.MaxElapsedTime <= 0 {
+		retryConfig.MaxElapsedTime = defaults.MaxElapsedTime
+	}
+
+	return &retryPolicy{config: retryConfig, logger: logger}
+}
+
+// canRetryRequest is invoked by the retrying queue for each failed attempt.  The queue keeps the modified request for
+// the next attempt.
+func (rp *retryPolicy) canRetryRequest(request *common.Request, result *common.DnsRecordResult) bool {
+	// Taken before the attempt is counted, so the entries refer to the attempt that failed
+	logArgs := request.LogArgs()
+	state := &request.Retry
+	now := time.Now()
+
+	if state.FirstAttemptTime.IsZero() {
+		state.FirstAttemptTime = now
+	}
+
+	elapsedExceeded := now.Sub(state.FirstAttemptTime) >= rp.config.MaxElapsedTime
+	state.FailedAttempts++
+
+	if !isRetryableError(result.Error) {
+		rp.logger.Info("Not retrying request, error is not retryable", append(logArgs, "error", result.Error)...)
+		return false
+	}
+
+	if state.FailedAttempts >= rp.config.MaxAttempts || elapsedExceeded {
+		rp.logger.Warn("Not retrying request, giving up", append(logArgs, "error", result.Error)...)
+		return false
+	}
+
+	interval := rp.backoff(state.FailedAttempts)
+	var apiErr *common.APIError
+
+	// Wait at least as long as the API asked
+	if errors.As(result.Error, &apiErr) && apiErr.RetryAfter > interval {
+		interval = apiErr.RetryAfter
+	}
+
+	state.NextAttemptTime = now.Add(interval)
+	rp.logger.Info("Retrying request",
+		append(logArgs, "next_attempt_time", state.NextAttemptTime, "error", result.Error)...)
+
+	return true
+}
+
+// backoff returns the interval to wait after the passed number of failed attempts.
+func (rp *retryPolicy) backoff(failedAttempts int) time.Duration {
+	interval := float64(rp.config.InitialInterval) * math.Pow(rp.config.Multiplier, float64(failedAttempts-1))
+	interval = math.Min(interval, float64(rp.config.MaxInterval))
+	// Spread the interval uniformly across [interval * (1 - jitter), interval * (1 + jitter)]
+	interval = interval * (1 + rp.config.Jitter*(2*rand.Float64()-1))
+
+	return time.Duration(interval)
+}
+
+// isRetryableError returns true for errors that are likely to be transient: network errors, timeouts, malformed
+// responses, server errors and rate limiting.  Authentication, validation and not found errors are not retried.
+func isRetryableError(err error) bool {
+	if errors.Is(err, common.ErrDnsRecordNotFound) || errors.Is(err, common.ErrUpdateSuperseded) ||
+		errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var apiErr *common.APIError
+
+	if errors.As(err, &apiErr) {
+		kind := apiErr.Kind()
+
+		return kind == common.APIErrorKindRateLimited || kind == common.APIErrorKindServer
+	}
+
+	return true
+}
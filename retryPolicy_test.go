@@ -0,0 +1,118 @@
+package porkbun
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/config"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+func newTestRetryPolicy() *retryPolicy {
+	return newRetryPolicy(config.RetryConfig{
+		MaxAttempts:     3,
+		InitialInterval: time.Minute,
+		MaxInterval:     3 * time.Minute,
+		Multiplier:      2,
+		Jitter:          0.01,
+		MaxElapsedTime:  time.Hour,
+	}, discardLogger)
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		retryable bool
+	}{
+		{name: "network", err: errors.New("connection refused"), retryable: true},
+		{name: "server", err: &common.APIError{HttpStatusCode: http.StatusBadGateway}, retryable: true},
+		{name: "rate limited", err: &common.APIError{HttpStatusCode: http.StatusTooManyRequests}, retryable: true},
+		{name: "auth", err: &common.APIError{HttpStatusCode: http.StatusForbidden}, retryable: false},
+		{name: "invalid", err: &common.APIError{HttpStatusCode: http.StatusBadRequest}, retryable: false},
+		{name: "not found", err: fmt.Errorf("wrapped: %w", common.ErrDnsRecordNotFound), retryable: false},
+		{name: "superseded", err: common.ErrUpdateSuperseded, retryable: false},
+		{name: "canceled", err: context.Canceled, retryable: false},
+		{name: "deadline", err: context.DeadlineExceeded, retryable: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if retryable := isRetryableError(test.err); retryable != test.retryable {
+				t.Errorf("got %t, want %t", retryable, test.retryable)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyBacksOff(t *testing.T) {
+	policy := newTestRetryPolicy()
+	request := common.Request{}
+	result := common.DnsRecordResult{Error: errors.New("connection refused")}
+
+	for _, expected := range []time.Duration{time.Minute, 2 * time.Minute} {
+		start := time.Now()
+
+		if !policy.canRetryRequest(&request, &result) {
+			t.Fatalf("got no retry after %d attempts, want a retry", request.Retry.FailedAttempts)
+		}
+
+		interval := request.Retry.NextAttemptTime.Sub(start)
+
+		if interval < expected*98/100 || interval > expected*102/100 {
+			t.Errorf("got interval %v after %d attempts, want about %v", interval, request.Retry.FailedAttempts,
+				expected)
+		}
+	}
+
+	if policy.canRetryRequest(&request, &result) {
+		t.Error("got a retry, want none after the maximum attempts")
+	}
+
+	if request.Retry.FailedAttempts != 3 {
+		t.Errorf("got %d failed attempts, want 3", request.Retry.FailedAttempts)
+	}
+}
+
+func TestRetryPolicyHonorsRetryAfter(t *testing.T) {
+	policy := newTestRetryPolicy()
+	request := common.Request{}
+	result := common.DnsRecordResult{
+		Error: &common.APIError{HttpStatusCode: http.StatusTooManyRequests, RetryAfter: 10 * time.Minute}}
+
+	if !policy.canRetryRequest(&request, &result) {
+		t.Fatal("got no retry, want a retry")
+	}
+
+	if interval := time.Until(request.Retry.NextAttemptTime); interval < 9*time.Minute {
+		t.Errorf("got interval %v, want at least the Retry-After delay", interval)
+	}
+}
+
+func TestRetryPolicyGivesUpAfterMaxElapsedTime(t *testing.T) {
+	policy := newTestRetryPolicy()
+	request := common.Request{Retry: common.RetryState{FirstAttemptTime: time.Now().Add(-2 * time.Hour)}}
+	result := common.DnsRecordResult{Error: errors.New("connection refused")}
+
+	if policy.canRetryRequest(&request, &result) {
+		t.Error("got a retry, want none after the maximum elapsed time")
+	}
+}
+
+func TestRetryPolicyDoesNotRetryPermanentErrors(t *testing.T) {
+	policy := newTestRetryPolicy()
+	request := common.Request{}
+	result := common.DnsRecordResult{Error: &common.APIError{HttpStatusCode: http.StatusForbidden}}
+
+	if policy.canRetryRequest(&request, &result) {
+		t.Error("got a retry, want none for an authentication error")
+	}
+}
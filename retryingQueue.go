@@ -0,0 +1,179 @@
+package porkbun
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/avanha/pmaas-common/queue"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+)
+
+// errRequestCanceled is the result of requests waiting for a retry when the queue is stopped.
+var errRequestCanceled = errors.New("request canceled")
+
+// retryingQueue sends requests to the workers via the request queue, and retries the failed requests that the retry
+// policy allows.  A request waiting for a retry is held on a timer until its next attempt time, so it only reaches a
+// worker, and is only counted as an attempt, once it is due.  It is failed as soon as its context is done.
+type retryingQueue struct {
+	requestQueue           *queue.RequestQueue[common.Request]
+	canRetryFn             func(*common.Request, *common.DnsRecordResult) bool
+	mutex                  sync.Mutex
+	pending                map[*pendingRetry]struct{}
+	stopped                bool
+	peakCount              int
+	peakCountTime          time.Time
+	peakFailedAttempts     int
+	peakFailedAttemptsTime time.Time
+}
+
+// pendingRetry is a request waiting for its next attempt.  The request holds the caller's result channel.
+type pendingRetry struct {
+	request       common.Request
+	timer         *time.Timer
+	stopContextFn func() bool
+}
+
+func newRetryingQueue(
+	requestQueue *queue.RequestQueue[common.Request],
+	canRetryFn func(*common.Request, *common.DnsRecordResult) bool) *retryingQueue {
+	return &retryingQueue{
+		requestQueue: requestQueue,
+		canRetryFn:   canRetryFn,
+		pending:      make(map[*pendingRetry]struct{}),
+	}
+}
+
+// Enqueue sends the request to the workers, and delivers the result to the request's result channel once the request
+// succeeds or is no longer retried.
+func (q *retryingQueue) Enqueue(request *common.Request) error {
+	return q.send(*request)
+}
+
+func (q *retryingQueue) send(request common.Request) error {
+	resultCh := request.ResultCh
+	attemptResultCh := make(chan common.DnsRecordResult)
+	request.ResultCh = attemptResultCh
+
+	if err := q.requestQueue.Enqueue(&request); err != nil {
+		return err
+	}
+
+	go func() {
+		result := <-attemptResultCh
+		request.ResultCh = resultCh
+
+		if result.Error != nil && q.canRetryFn(&request, &result) && q.hold(request) {
+			return
+		}
+
+		completeRequest(resultCh, result)
+	}()
+
+	return nil
+}
+
+// hold keeps the request until its next attempt time.  It returns false if the queue is stopped.
+func (q *retryingQueue) hold(request common.Request) bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if q.stopped {
+		return false
+	}
+
+	retry := &pendingRetry{request: request}
+	q.pending[retry] = struct{}{}
+	now := time.Now()
+
+	if len(q.pending) > q.peakCount {
+		q.peakCount = len(q.pending)
+		q.peakCountTime = now
+	}
+
+	if request.Retry.FailedAttempts > q.peakFailedAttempts {
+		q.peakFailedAttempts = request.Retry.FailedAttempts
+		q.peakFailedAttemptsTime = now
+	}
+
+	retry.timer = time.AfterFunc(request.Retry.NextAttemptTime.Sub(now), func() { q.release(retry, nil) })
+
+	// Don't keep requests that were abandoned, for example, updates superseded by a later update, until their next
+	// attempt
+	if request.Context != nil {
+		retry.stopContextFn = context.AfterFunc(request.Context, func() {
+			q.release(retry, context.Cause(request.Context))
+		})
+	}
+
+	return true
+}
+
+// release stops holding the request, and either sends it for its next attempt, or, if err is set, fails it with err.
+// Only the first release of a request has an effect.
+func (q *retryingQueue) release(retry *pendingRetry, err error) {
+	q.mutex.Lock()
+	_, ok := q.pending[retry]
+	delete(q.pending, retry)
+	q.mutex.Unlock()
+
+	if !ok {
+		return
+	}
+
+	retry.timer.Stop()
+
+	if retry.stopContextFn != nil {
+		retry.stopContextFn()
+	}
+
+	if err == nil {
+		err = q.send(retry.request)
+
+		if err == nil {
+			return
+		}
+	}
+
+	completeRequest(retry.request.ResultCh, common.DnsRecordResult{Error: err})
+}
+
+// Stop fails the requests waiting for a retry, and the requests that fail from then on.
+func (q *retryingQueue) Stop() {
+	q.mutex.Lock()
+	q.stopped = true
+	retries := make([]*pendingRetry, 0, len(q.pending))
+
+	for retry := range q.pending {
+		retries = append(retries, retry)
+	}
+
+	q.mutex.Unlock()
+
+	for _, retry := range retries {
+		q.release(retry, errRequestCanceled)
+	}
+}
+
+func (q *retryingQueue) Stats() queue.RetryingQueueStats {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	return queue.RetryingQueueStats{
+		QueueStats: queue.QueueStats{
+			CurrentCount:  len(q.pending),
+			PeakCount:     q.peakCount,
+			PeakCountTime: q.peakCountTime,
+		},
+		PeakFailedAttempts:     q.peakFailedAttempts,
+		PeakFailedAttemptsTime: q.peakFailedAttemptsTime,
+	}
+}
+
+func completeRequest(resultCh chan common.DnsRecordResult, result common.DnsRecordResult) {
+	if resultCh != nil {
+		resultCh <- result
+		close(resultCh)
+	}
+}
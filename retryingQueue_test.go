@@ -0,0 +1,128 @@
+package porkbun
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-common/queue"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+)
+
+// newTestRetryingQueue returns a queue whose requests are answered with the passed results, in order, and a channel
+// receiving the time of each attempt.  The queue retries failed requests after retryInterval.
+func newTestRetryingQueue(
+	t *testing.T, retryInterval time.Duration, results ...common.DnsRecordResult) (*retryingQueue, chan time.Time) {
+	t.Helper()
+	requestCh := make(chan common.Request)
+	requestQueue := queue.NewRequestQueue(requestCh)
+	attemptCh := make(chan time.Time, len(results))
+	go requestQueue.Run()
+	t.Cleanup(requestQueue.Stop)
+
+	go func() {
+		for request := range requestCh {
+			attemptCh <- time.Now()
+			result := results[0]
+			results = results[1:]
+			request.ResultCh <- result
+			close(request.ResultCh)
+		}
+	}()
+
+	canRetry := func(request *common.Request, result *common.DnsRecordResult) bool {
+		request.Retry.FailedAttempts++
+		request.Retry.NextAttemptTime = time.Now().Add(retryInterval)
+
+		return true
+	}
+
+	return newRetryingQueue(requestQueue, canRetry), attemptCh
+}
+
+func enqueue(t *testing.T, q *retryingQueue) chan common.DnsRecordResult {
+	t.Helper()
+	resultCh := make(chan common.DnsRecordResult, 1)
+
+	if err := q.Enqueue(&common.Request{ResultCh: resultCh}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	return resultCh
+}
+
+func TestRetryingQueueHoldsRetryUntilNextAttemptTime(t *testing.T) {
+	failure := common.DnsRecordResult{Error: errors.New("connection refused")}
+	q, attemptCh := newTestRetryingQueue(t, 50*time.Millisecond, failure, failure, common.DnsRecordResult{})
+	result := <-enqueue(t, q)
+
+	if result.Error != nil {
+		t.Errorf("got error %v, want the result of the successful attempt", result.Error)
+	}
+
+	first, second, third := <-attemptCh, <-attemptCh, <-attemptCh
+
+	if second.Sub(first) < 50*time.Millisecond || third.Sub(second) < 50*time.Millisecond {
+		t.Errorf("got attempts %v and %v apart, want at least the retry interval", second.Sub(first),
+			third.Sub(second))
+	}
+
+	stats := q.Stats()
+
+	if stats.CurrentCount != 0 || stats.PeakCount != 1 || stats.PeakFailedAttempts != 2 {
+		t.Errorf("got %+v, want no pending retries, a peak of 1 and 2 failed attempts", stats)
+	}
+}
+
+func TestRetryingQueueStopFailsPendingRetries(t *testing.T) {
+	q, attemptCh := newTestRetryingQueue(t, time.Hour, common.DnsRecordResult{Error: errors.New("connection refused")})
+	resultCh := enqueue(t, q)
+	<-attemptCh
+
+	// Wait for the failed attempt to be held
+	for q.Stats().CurrentCount == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	q.Stop()
+
+	if result := <-resultCh; !errors.Is(result.Error, errRequestCanceled) {
+		t.Errorf("got error %v, want %v", result.Error, errRequestCanceled)
+	}
+
+	if count := q.Stats().CurrentCount; count != 0 {
+		t.Errorf("got %d pending retries, want none", count)
+	}
+}
+
+func TestRetryingQueueReleasesAbandonedRetries(t *testing.T) {
+	q, attemptCh := newTestRetryingQueue(t, time.Hour, common.DnsRecordResult{Error: errors.New("connection refused")})
+	ctx, cancel := context.WithCancelCause(context.Background())
+	resultCh := make(chan common.DnsRecordResult, 1)
+
+	if err := q.Enqueue(&common.Request{ResultCh: resultCh, Context: ctx}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	<-attemptCh
+
+	for q.Stats().CurrentCount == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	cancel(common.ErrUpdateSuperseded)
+
+	select {
+	case result := <-resultCh:
+		if !errors.Is(result.Error, common.ErrUpdateSuperseded) {
+			t.Errorf("got error %v, want %v", result.Error, common.ErrUpdateSuperseded)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("got no result, want the retry released once its context is done")
+	}
+
+	if count := q.Stats().CurrentCount; count != 0 {
+		t.Errorf("got %d pending retries, want none", count)
+	}
+}
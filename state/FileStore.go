@@ -0,0 +1,70 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// FileStore saves the records as JSON to a file.
+type FileStore struct {
+	path string
+}
+
+func NewFileStore(path string) *FileStore {
+	return &FileStore{path: path}
+}
+
+func (s *FileStore) Load() ([]DnsRecordState, error) {
+	fileBytes, err := os.ReadFile(s.path)
+
+	if errors.Is(err, fs.ErrNotExist) {
+		return make([]DnsRecordState, 0), nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable to read state file %s: %w", s.path, err)
+	}
+
+	records := make([]DnsRecordState, 0)
+
+	if err = json.Unmarshal(fileBytes, &records); err != nil {
+		return nil, fmt.Errorf("unable to parse state file %s: %w", s.path, err)
+	}
+
+	return records, nil
+}
+
+// Save writes the records to a temporary file, and then replaces the file, so a crash never leaves a partially
+// written file behind.
+func (s *FileStore) Save(records []DnsRecordState) error {
+	fileBytes, err := json.MarshalIndent(records, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("unable to serialize state: %w", err)
+	}
+
+	tempFile, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+
+	if err != nil {
+		return fmt.Errorf("unable to create temporary state file: %w", err)
+	}
+
+	tempPath := tempFile.Name()
+	_, err = tempFile.Write(fileBytes)
+	err = errors.Join(err, tempFile.Close())
+
+	if err == nil {
+		err = os.Rename(tempPath, s.path)
+	}
+
+	if err != nil {
+		_ = os.Remove(tempPath)
+		return fmt.Errorf("unable to write state file %s: %w", s.path, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/data"
+)
+
+func TestFileStoreLoadsMissingFileAsEmpty(t *testing.T) {
+	store := NewFileStore(filepath.Join(t.TempDir(), "state.json"))
+	records, err := store.Load()
+
+	if err != nil || len(records) != 0 {
+		t.Errorf("got %v and error %v, want no records", records, err)
+	}
+}
+
+func TestFileStoreSaveAndLoad(t *testing.T) {
+	directory := t.TempDir()
+	store := NewFileStore(filepath.Join(directory, "state.json"))
+	updateTime := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	records := []DnsRecordState{
+		{
+			Domain: "example.com",
+			Type:   "A",
+			Name:   "www",
+			Data: data.DnsRecordData{
+				Id: "123", Name: "www", Type: "A", Value: "192.0.2.1", Ttl: 600, LastUpdateTime: updateTime,
+				UpdateSuccessCount: 2,
+			},
+			LastWritten: &data.DnsRecordSpec{Value: "192.0.2.1", Ttl: 600},
+			History: []data.DnsRecordChange{
+				{
+					Time:   updateTime,
+					Source: data.DnsRecordChangeSourceCaller,
+					New:    &data.DnsRecordSpec{Value: "192.0.2.1", Ttl: 600},
+				},
+			},
+		},
+	}
+
+	if err := store.Save(records); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	loaded, err := store.Load()
+
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !reflect.DeepEqual(loaded, records) {
+		t.Errorf("got %+v, want %+v", loaded, records)
+	}
+
+	// The temporary file is renamed over the state file
+	entries, _ := os.ReadDir(directory)
+
+	if len(entries) != 1 {
+		t.Errorf("got %d files, want only the state file", len(entries))
+	}
+}
+
+func TestFileStoreLoadsInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	if err := os.WriteFile(path, []byte("{"), 0600); err != nil {
+		t.Fatalf("unable to write file: %v", err)
+	}
+
+	if _, err := NewFileStore(path).Load(); err == nil {
+		t.Error("got no error, want an error for an invalid file")
+	}
+}
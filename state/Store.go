@@ -0,0 +1,23 @@
+package state
+
+import "github.com/avanha/pmaas-plugin-porkbun/data"
+
+// Store saves the state of the configured DNS records, so it survives restarts of the plugin.  The plugin calls Load
+// once, while processing its configuration, and Save from its main goroutine after records change.
+type Store interface {
+	// Load returns the saved records, or an empty slice if nothing was saved yet.
+	Load() ([]DnsRecordState, error)
+	// Save replaces the saved records with the passed ones.
+	Save(records []DnsRecordState) error
+}
+
+// DnsRecordState is the saved state of a configured DNS record.
+type DnsRecordState struct {
+	Domain string
+	Type   string
+	Name   string
+	Data   data.DnsRecordData
+	// LastWritten is the state last written by the plugin, used to detect changes made outside the plugin
+	LastWritten *data.DnsRecordSpec
+	History     []data.DnsRecordChange
+}
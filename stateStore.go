@@ -0,0 +1,79 @@
+package porkbun
+
+import (
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/state"
+)
+
+// stateSaveDelay batches the changes of many records, for example during a refresh, into a single save.
+const stateSaveDelay = 5 * time.Second
+
+func (p *plugin) initStateStore() {
+	if p.config.StateStore != nil {
+		p.stateStore = p.config.StateStore
+	} else if p.config.StateFile != "" {
+		p.stateStore = state.NewFileStore(p.config.StateFile)
+	}
+}
+
+// loadState returns the saved states of the configured records, keyed like the plugin's dnsRecords map.
+func (p *plugin) loadState() map[string]*state.DnsRecordState {
+	savedStates := make(map[string]*state.DnsRecordState)
+
+	if p.stateStore == nil {
+		return savedStates
+	}
+
+	records, err := p.stateStore.Load()
+
+	if err != nil {
+		p.logger.Error("Unable to load the saved state of the DNS records", "error", err)
+		return savedStates
+	}
+
+	for i := range records {
+		savedStates[dnsRecordKey(records[i].Domain, records[i].Type, records[i].Name)] = &records[i]
+	}
+
+	return savedStates
+}
+
+// scheduleStateSave saves the state of the records after stateSaveDelay, unless a save is already scheduled.  Must be
+// called from the main plugin goroutine.
+func (p *plugin) scheduleStateSave() {
+	if p.stateStore == nil || p.stateSaveScheduled {
+		return
+	}
+
+	p.stateSaveScheduled = true
+	time.AfterFunc(stateSaveDelay, func() {
+		err := p.container.EnqueueOnPluginGoRoutine(p.saveState)
+
+		if err != nil {
+			p.logger.Error("Unable to enqueue saving the state of the DNS records", "error", err)
+		}
+	})
+}
+
+// saveState saves the state of the configured records.  Discovered records aren't saved, since they're retrieved
+// again on the first refresh of their domain.  Must be called from the main plugin goroutine.
+func (p *plugin) saveState() {
+	p.stateSaveScheduled = false
+
+	if p.stateStore == nil {
+		return
+	}
+
+	records := make([]state.DnsRecordState, 0, len(p.dnsRecords))
+
+	for _, record := range p.dnsRecords {
+		if !record.Discovered() {
+			records = append(records, record.State())
+		}
+	}
+
+	if err := p.stateStore.Save(records); err != nil {
+		p.logger.Error("Unable to save the state of the DNS records", "error", err)
+	}
+}
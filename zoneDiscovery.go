@@ -0,0 +1,101 @@
+package porkbun
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/avanha/pmaas-plugin-porkbun/internal/common"
+	"github.com/avanha/pmaas-plugin-porkbun/internal/dnsRecord"
+)
+
+// discoveredDnsRecordKey returns the key of a discovered record in the plugin's dnsRecords map.  Discovered records
+// are keyed by their Porkbun id, since a domain can contain multiple records with the same name and type.
+func discoveredDnsRecordKey(domain string, recordId string) string {
+	return fmt.Sprintf("discovered_%s_%s", domain, recordId)
+}
+
+// enqueueDomainDiscovery sends a request to retrieve all the records of the passed domain.  Must be called from the
+// main plugin goroutine.
+func (p *plugin) enqueueDomainDiscovery(domain string) error {
+	resultCh := make(chan common.DnsRecordResult)
+	request := common.Request{
+		RequestType:           common.RequestTypeRetrieveDomain,
+		ResultCh:              resultCh,
+		RetrieveDomainRequest: common.RetrieveDomainRequest{Domain: domain},
+	}
+
+	err := p.enqueueRequest(request)
+
+	if err != nil {
+		return fmt.Errorf("failed to enqueue %s domain retrieval: %w", domain, err)
+	}
+
+	go func() {
+		result := <-resultCh
+		err := p.container.EnqueueOnPluginGoRoutine(func() { p.processDomainDiscoveryResult(domain, result) })
+
+		if err != nil {
+			p.logger.Error("Error processing domain retrieval result", "domain", domain, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// processDomainDiscoveryResult reconciles the discovered records of a domain with the retrieved records, registering
+// entities for new records, and deregistering entities for records that no longer exist.
+func (p *plugin) processDomainDiscoveryResult(domain string, result common.DnsRecordResult) {
+	if result.Error != nil {
+		p.logger.Error("Error retrieving domain records", "domain", domain, "error", result.Error)
+
+		if schedule, ok := p.domainPollSchedules[domain]; ok {
+			schedule.Failed(time.Now())
+		}
+
+		return
+	}
+
+	if !p.running {
+		return
+	}
+
+	p.logger.Info(result.Message, "domain", domain)
+	seenKeys := make(map[string]bool, len(result.DomainRecords))
+
+	for i := range result.DomainRecords {
+		recordData := &result.DomainRecords[i]
+
+		// Configured records are already managed by their own entities
+		if configured, ok := p.dnsRecords[dnsRecordKey(domain, recordData.Type, recordData.Name)]; ok &&
+			!configured.Discovered() {
+			continue
+		}
+
+		key := discoveredDnsRecordKey(domain, recordData.Id)
+		seenKeys[key] = true
+
+		if record, ok := p.dnsRecords[key]; ok {
+			record.ApplyDiscoveredData(recordData)
+			continue
+		}
+
+		record := dnsRecord.NewDiscoveredDnsRecord(
+			p.container,
+			fmt.Sprintf("DnsRecord_%v", p.nextEntityId()),
+			domain,
+			recordData,
+			p.logger)
+		p.dnsRecords[key] = record
+		p.registerEntity(key, record)
+	}
+
+	for key, record := range p.dnsRecords {
+		if record.Discovered() && record.Domain() == domain && !seenKeys[key] {
+			recordData := record.Data()
+			p.logger.Info("Discovered DNS record no longer exists, removing",
+				"domain", domain, "type", recordData.Type, "name", recordData.Name, "record_id", recordData.Id)
+			p.deregisterEntity(key, record)
+			delete(p.dnsRecords, key)
+		}
+	}
+}